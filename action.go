@@ -0,0 +1,39 @@
+package flowmatic
+
+import (
+	"context"
+)
+
+// Action tells the task management loop how to proceed after a call to an ActionManager.
+type Action int
+
+const (
+	// Continue queues the inputs returned by the manager and continues processing.
+	Continue Action = iota
+	// DrainAndStop stops accepting new inputs,
+	// but finishes the inputs which are already queued or in flight.
+	// The manager is still called with their results,
+	// but any inputs it returns are discarded.
+	DrainAndStop
+	// StopNow halts processing immediately.
+	// Queued inputs are discarded,
+	// and the results of in-flight tasks are waited for but not given to the manager.
+	StopNow
+)
+
+// ActionManager is like Manager,
+// but it returns an Action instead of a bool
+// to choose between continuing, draining, and stopping immediately.
+type ActionManager[Input, Output any] func(Input, Output, error) (tasks []Input, action Action)
+
+// ManageTasksAction is like ManageTasks,
+// but the manager returns an Action.
+// Once the manager returns DrainAndStop,
+// inputs it returns are discarded,
+// but the inputs already queued are still processed.
+// The manager can still return StopNow to halt processing immediately while draining.
+func ManageTasksAction[Input, Output any](numWorkers int, task Task[Input, Output], manager ActionManager[Input, Output], initial ...Input) {
+	_ = rethrow(manageFunc(context.Background(), numWorkers, ignoreContext(task), func(r Result[Input, Output]) ([]Input, Action) {
+		return manager(r.In, r.Out, r.Err)
+	}, newFIFO(initial...)))
+}
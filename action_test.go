@@ -0,0 +1,45 @@
+package flowmatic_test
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+func TestManageTasksAction(t *testing.T) {
+	task := func(n int) (int, error) {
+		return n, nil
+	}
+	var seen []int
+	manager := func(in, out int, err error) ([]int, flowmatic.Action) {
+		seen = append(seen, in)
+		switch in {
+		case 0:
+			return []int{1, 2, 3}, flowmatic.Continue
+		case 1:
+			// 2 and 3 are still processed, but 10 is not
+			return []int{10}, flowmatic.DrainAndStop
+		}
+		return []int{in + 100}, flowmatic.Continue
+	}
+	flowmatic.ManageTasksAction(1, task, manager, 0)
+	slices.Sort(seen)
+	if s := fmt.Sprint(seen); s != "[0 1 2 3]" {
+		t.Fatal(s)
+	}
+
+	seen = nil
+	manager = func(in, out int, err error) ([]int, flowmatic.Action) {
+		seen = append(seen, in)
+		if in == 0 {
+			return []int{1, 2, 3}, flowmatic.StopNow
+		}
+		return nil, flowmatic.Continue
+	}
+	flowmatic.ManageTasksAction(1, task, manager, 0)
+	if s := fmt.Sprint(seen); s != "[0]" {
+		t.Fatal(s)
+	}
+}
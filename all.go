@@ -2,6 +2,7 @@ package flowmatic
 
 import (
 	"context"
+	"errors"
 )
 
 // All runs each task concurrently
@@ -10,26 +11,42 @@ import (
 // which is canceled once one task returns an error or panics.
 // All returns nil if all tasks succeed.
 // Otherwise,
-// All returns a multierror containing the errors encountered.
+// All returns a multierror containing the errors encountered,
+// ordered by the position of the task that returned them.
 // If a task panics during execution,
-// a panic will be caught and rethrown in the parent Goroutine.
+// a panic will be caught and rethrown in the parent Goroutine
+// once all the tasks have finished.
+// If more than one task panics,
+// exactly one panic is rethrown,
+// that of the first of those tasks in the argument list,
+// as with Do.
+// The other panics are discarded
+// rather than being combined into a MultiPanic.
 func All(ctx context.Context, tasks ...func(context.Context) error) error {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	return eachN(len(tasks), len(tasks), func(pos int) error {
+	errs := make([]error, len(tasks))
+	panics := make([]any, len(tasks))
+	_ = eachN(len(tasks), len(tasks), func(pos int) error {
 		defer func() {
 			panicVal := recover()
 			if panicVal != nil {
 				cancel()
-				panic(panicVal)
+				panics[pos] = panicVal
 			}
 		}()
 		err := tasks[pos](ctx)
 		if err != nil {
 			cancel()
-			return err
+			errs[pos] = err
 		}
 		return nil
 	})
+	for _, panicVal := range panics {
+		if panicVal != nil {
+			panic(panicVal)
+		}
+	}
+	return errors.Join(errs...)
 }
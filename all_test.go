@@ -0,0 +1,57 @@
+package flowmatic_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+func TestAll_join_errs(t *testing.T) {
+	a := errors.New("a")
+	b := errors.New("b")
+	err := flowmatic.All(context.Background(),
+		func(context.Context) error {
+			// finishes last, but is reported first
+			time.Sleep(10 * time.Millisecond)
+			return a
+		},
+		func(context.Context) error {
+			return b
+		},
+		func(context.Context) error {
+			return nil
+		},
+	)
+	if err == nil || err.Error() != "a\nb" {
+		t.Fatal(err)
+	}
+}
+
+func TestAll_firstPanic(t *testing.T) {
+	var finished bool
+	r := try(func() {
+		_ = flowmatic.All(context.Background(),
+			func(context.Context) error {
+				panic("one")
+			},
+			func(context.Context) error {
+				time.Sleep(10 * time.Millisecond)
+				finished = true
+				return nil
+			},
+			func(context.Context) error {
+				panic("two")
+			},
+		)
+	})
+	if !finished {
+		t.Fatal("rethrew before all tasks finished")
+	}
+	// only the panic of the first task to panic is rethrown
+	if r != "one" {
+		t.Fatal(r)
+	}
+}
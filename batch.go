@@ -0,0 +1,28 @@
+package flowmatic
+
+import (
+	"context"
+)
+
+// BatchManager is like Manager,
+// but it examines the results of a batch of inputs.
+type BatchManager[Input, Output any] func(batch []Input, out []Output, err error) (tasks []Input, ok bool)
+
+// ManageBatches is like ManageTasks,
+// but each task processes a batch of up to batchSize queued inputs.
+// Inputs are batched in first-in, first-out order
+// as workers become free,
+// so a batch is never held back waiting to be filled:
+// when fewer than batchSize inputs are queued,
+// they are dispatched together as a partial batch.
+// The manager receives the results of each batch
+// and returns individual inputs to be queued.
+// If a task panics during execution,
+// the panic will be caught and rethrown in the parent Goroutine.
+func ManageBatches[Input, Output any](numWorkers, batchSize int, task func([]Input) ([]Output, error), manager BatchManager[Input, Output], initial ...Input) {
+	m := func(r Result[[]Input, []Output]) ([][]Input, Action) {
+		items, ok := manager(r.In, r.Out, r.Err)
+		return [][]Input{items}, continueIf(ok)
+	}
+	_ = rethrow(manageFunc(context.Background(), numWorkers, ignoreContext(task), m, newBatches(batchSize, initial...)))
+}
@@ -0,0 +1,45 @@
+package flowmatic_test
+
+import (
+	"testing"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+func TestManageBatches(t *testing.T) {
+	task := func(batch []int) ([]int, error) {
+		out := make([]int, len(batch))
+		for i, n := range batch {
+			out[i] = n * 2
+		}
+		return out, nil
+	}
+	seen := map[int]int{}
+	sum := 0
+	manager := func(batch, out []int, err error) ([]int, bool) {
+		if len(batch) > 3 || len(batch) != len(out) {
+			t.Fatal(batch, out)
+		}
+		var more []int
+		for i, n := range batch {
+			seen[n]++
+			sum += out[i]
+			if n < 10 {
+				more = append(more, n+10)
+			}
+		}
+		return more, true
+	}
+	flowmatic.ManageBatches(1, 3, task, manager, 0, 1, 2, 3, 4, 5, 6)
+	if len(seen) != 14 {
+		t.Fatal(seen)
+	}
+	for n, count := range seen {
+		if count != 1 {
+			t.Fatal(n, count)
+		}
+	}
+	if sum != 2*(21+91) {
+		t.Fatal(sum)
+	}
+}
@@ -0,0 +1,99 @@
+package flowmatic
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// Cancels tracks the contexts of running tasks by key,
+// so that one task can be canceled while the others keep running,
+// such as when the client which asked for it disconnects.
+// Wrap a task with Cancelable to track its context.
+// The zero value is ready to use,
+// and a Cancels is safe for concurrent use.
+type Cancels[Key comparable] struct {
+	mu       sync.Mutex
+	running  map[Key][]*context.CancelCauseFunc
+	canceled map[Key]struct{}
+}
+
+// Cancel cancels the context of each running task with key,
+// with ErrInputCanceled as the cause,
+// and reports whether there were any.
+// The key stays canceled until Forget is called,
+// so inputs with key which are still queued
+// fail without running once a worker reaches them.
+func (c *Cancels[Key]) Cancel(key Key) bool {
+	c.mu.Lock()
+	if c.canceled == nil {
+		c.canceled = make(map[Key]struct{})
+	}
+	c.canceled[key] = struct{}{}
+	cancels := c.running[key]
+	c.mu.Unlock()
+	for _, cancel := range cancels {
+		(*cancel)(ErrInputCanceled)
+	}
+	return len(cancels) > 0
+}
+
+// Forget clears the cancellation of key by Cancel,
+// so that inputs with key which start afterwards run as usual.
+// Call it once no more inputs with key can be queued
+// to stop tracking the key.
+func (c *Cancels[Key]) Forget(key Key) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.canceled, key)
+}
+
+// add tracks cancel under key until the returned function is called.
+// If key has been canceled, add does not track cancel and returns false.
+func (c *Cancels[Key]) add(key Key, cancel context.CancelCauseFunc) (remove func(), ok bool) {
+	p := &cancel
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, canceled := c.canceled[key]; canceled {
+		return nil, false
+	}
+	if c.running == nil {
+		c.running = make(map[Key][]*context.CancelCauseFunc)
+	}
+	c.running[key] = append(c.running[key], p)
+	return func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		// copy rather than delete in place,
+		// since Cancel may be ranging over the old slice
+		cancels := slices.DeleteFunc(slices.Clone(c.running[key]), func(q *context.CancelCauseFunc) bool {
+			return q == p
+		})
+		if len(cancels) == 0 {
+			delete(c.running, key)
+			return
+		}
+		c.running[key] = cancels
+	}, true
+}
+
+// Cancelable wraps task so that its context can be canceled with c
+// using the key returned by keyOf for its input.
+// The context is tracked only while the task runs.
+// If the key has already been canceled,
+// task is not run, and the wrapped task returns an error
+// wrapping both ErrInputCanceled and context.Canceled.
+func Cancelable[Input any, Key comparable, Output any](c *Cancels[Key], keyOf func(Input) Key, task CTask[Input, Output]) CTask[Input, Output] {
+	return func(ctx context.Context, in Input) (Output, error) {
+		ctx, cancel := context.WithCancelCause(ctx)
+		defer cancel(nil)
+		remove, ok := c.add(keyOf(in), cancel)
+		if !ok {
+			var out Output
+			return out, fmt.Errorf("%w: %w", ErrInputCanceled, context.Canceled)
+		}
+		defer remove()
+		return task(ctx, in)
+	}
+}
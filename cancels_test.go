@@ -0,0 +1,108 @@
+package flowmatic_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+func TestCancelable(t *testing.T) {
+	var (
+		cancels flowmatic.Cancels[string]
+		started sync.WaitGroup
+	)
+	started.Add(3)
+	release := make(chan struct{})
+	task := func(ctx context.Context, job string) (string, error) {
+		started.Done()
+		select {
+		case <-ctx.Done():
+			return "", context.Cause(ctx)
+		case <-release:
+			return job, nil
+		}
+	}
+	go func() {
+		started.Wait()
+		if !cancels.Cancel("b") {
+			t.Error("b was not running")
+		}
+		if cancels.Cancel("z") {
+			t.Error("z was running")
+		}
+		close(release)
+	}()
+	results := map[string]error{}
+	manager := func(in, out string, err error) ([]string, bool) {
+		results[in] = err
+		return nil, true
+	}
+	keyOf := func(job string) string { return job }
+	err := flowmatic.ManageTasksWith(context.Background(), 3,
+		flowmatic.Cancelable(&cancels, keyOf, task), manager, []string{"a", "b", "c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 || results["a"] != nil || results["c"] != nil ||
+		!errors.Is(results["b"], flowmatic.ErrInputCanceled) {
+		t.Fatal(results)
+	}
+	// finished tasks are no longer tracked
+	if cancels.Cancel("a") {
+		t.Fatal("a still tracked")
+	}
+}
+
+func TestCancelable_queued(t *testing.T) {
+	var cancels flowmatic.Cancels[string]
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var ran []string
+	task := func(ctx context.Context, job string) (string, error) {
+		ran = append(ran, job)
+		if job == "a" {
+			close(started)
+			<-release
+		}
+		return job, nil
+	}
+	go func() {
+		<-started
+		// b is still queued behind a
+		if cancels.Cancel("b") {
+			t.Error("b was running")
+		}
+		close(release)
+	}()
+	results := map[string]error{}
+	manager := func(in, out string, err error) ([]string, bool) {
+		results[in] = err
+		return nil, true
+	}
+	keyOf := func(job string) string { return job }
+	err := flowmatic.ManageTasksWith(context.Background(), 1,
+		flowmatic.Cancelable(&cancels, keyOf, task), manager, []string{"a", "b", "c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 || results["a"] != nil || results["c"] != nil ||
+		!errors.Is(results["b"], context.Canceled) ||
+		!errors.Is(results["b"], flowmatic.ErrInputCanceled) {
+		t.Fatal(results)
+	}
+	if s := fmt.Sprint(ran); s != "[a c]" {
+		t.Fatal(s)
+	}
+	// once forgotten, the key runs again
+	cancels.Forget("b")
+	ran = nil
+	err = flowmatic.ManageTasksWith(context.Background(), 1,
+		flowmatic.Cancelable(&cancels, keyOf, task), manager, []string{"b"})
+	if err != nil || results["b"] != nil || fmt.Sprint(ran) != "[b]" {
+		t.Fatal(err, results, ran)
+	}
+}
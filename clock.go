@@ -0,0 +1,61 @@
+package flowmatic
+
+import (
+	"time"
+)
+
+// Clock tells the time and makes timers for the task management loop
+// and for task wrappers such as WithTimeout.
+// It is set with WithClock,
+// so that tests can control time with a fake Clock
+// instead of sleeping.
+type Clock interface {
+	Now() time.Time
+	// After is like time.After.
+	After(d time.Duration) <-chan time.Time
+	// NewTimer is like time.NewTimer.
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is a timer made by a Clock, like a *time.Timer.
+type Timer interface {
+	// C returns the channel on which the time is delivered.
+	C() <-chan time.Time
+	// Stop is like (*time.Timer).Stop.
+	Stop() bool
+}
+
+// WithClock makes the loop use c instead of the system clock
+// for the Progress, IdleTimeout, StallTimeout, RampUp, DrainTimeout,
+// and CircuitBreaker timings,
+// the delays of ManageTasksDelayedWith,
+// and to measure Result.Duration.
+// The task wrappers WithTimeout, Retry, RetryContext, Hedge, and RateLimit
+// also take WithClock as an option,
+// so pass the same c to them;
+// they ignore any other options.
+func WithClock(c Clock) Option {
+	return func(o *options) {
+		o.clk = c
+	}
+}
+
+// clock returns the Clock set with WithClock, or the system clock.
+func (o *options) clock() Clock {
+	if o.clk != nil {
+		return o.clk
+	}
+	return systemClock{}
+}
+
+// systemClock is the Clock of the time package.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (systemClock) NewTimer(d time.Duration) Timer         { return systemTimer{time.NewTimer(d)} }
+
+type systemTimer struct{ t *time.Timer }
+
+func (t systemTimer) C() <-chan time.Time { return t.t.C }
+func (t systemTimer) Stop() bool          { return t.t.Stop() }
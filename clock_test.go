@@ -0,0 +1,238 @@
+package flowmatic_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/carlmjohnson/flowmatic"
+	"golang.org/x/time/rate"
+)
+
+// fakeClock is a Clock whose time only moves when Advance is called.
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	timers  []*fakeTimer
+	created int
+}
+
+type fakeTimer struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.ch }
+func (t *fakeTimer) Stop() bool          { return false }
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	return c.NewTimer(d).C()
+}
+
+func (c *fakeClock) NewTimer(d time.Duration) flowmatic.Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.created++
+	t := &fakeTimer{c.now.Add(d), make(chan time.Time, 1)}
+	if d <= 0 {
+		t.ch <- c.now
+		return t
+	}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+// Advance moves the time forward by d and fires the timers which are due.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	pending := c.timers[:0]
+	for _, t := range c.timers {
+		if t.at.After(c.now) {
+			pending = append(pending, t)
+			continue
+		}
+		t.ch <- c.now
+	}
+	c.timers = pending
+}
+
+// AdvanceEach moves the time forward by d
+// after each of the next n timers is made.
+func (c *fakeClock) AdvanceEach(d time.Duration, n int) {
+	seen := c.Created()
+	go func() {
+		for range n {
+			for c.Created() <= seen {
+				time.Sleep(time.Millisecond)
+			}
+			seen = c.Created()
+			c.Advance(d)
+		}
+	}()
+}
+
+// Created returns the number of timers made so far.
+func (c *fakeClock) Created() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.created
+}
+
+func TestWithClock(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	task := func(_ context.Context, n int) (int, error) {
+		clock.Advance(time.Duration(n) * time.Second)
+		return n, nil
+	}
+	var durations []time.Duration
+	onComplete := flowmatic.OnComplete(func(r flowmatic.Result[int, int]) {
+		durations = append(durations, r.Duration)
+	})
+	timers := make(chan int, 1)
+	done := 0
+	manager := func(in, out int, err error) ([]int, bool) {
+		done++
+		if done == 3 {
+			// the next timer made is the idle timeout
+			timers <- clock.Created()
+		}
+		return nil, true
+	}
+	inputs := make(chan int, 3)
+	inputs <- 1
+	inputs <- 2
+	inputs <- 3
+	go func() {
+		n := <-timers
+		for clock.Created() <= n {
+			time.Sleep(time.Millisecond)
+		}
+		clock.Advance(time.Hour)
+	}()
+	err := flowmatic.ManageTasksChanWith(context.Background(), 1, task, manager, inputs,
+		flowmatic.IdleTimeout(time.Hour), flowmatic.WithClock(clock), onComplete)
+	if !errors.Is(err, flowmatic.ErrIdle) {
+		t.Fatal(err)
+	}
+	for i, d := range durations {
+		if d != time.Duration(i+1)*time.Second {
+			t.Fatal(durations)
+		}
+	}
+	if len(durations) != 3 {
+		t.Fatal(durations)
+	}
+}
+
+func TestWithClock_wrappers(t *testing.T) {
+	bad := errors.New("bad")
+	t.Run("WithTimeout", func(t *testing.T) {
+		clock := &fakeClock{now: time.Unix(0, 0)}
+		release := make(chan struct{})
+		defer close(release)
+		task := flowmatic.WithTimeout(time.Hour, func(n int) (int, error) {
+			<-release
+			return n, nil
+		}, flowmatic.WithClock(clock))
+		clock.AdvanceEach(time.Hour, 1)
+		if _, err := task(1); err != flowmatic.ErrTaskTimeout {
+			t.Fatal(err)
+		}
+	})
+	t.Run("Retry", func(t *testing.T) {
+		clock := &fakeClock{now: time.Unix(0, 0)}
+		tries := 0
+		task := flowmatic.Retry(3, func(int) time.Duration { return time.Hour }, nil,
+			func(n int) (int, error) {
+				tries++
+				return n, bad
+			}, flowmatic.WithClock(clock))
+		clock.AdvanceEach(time.Hour, 2)
+		if _, err := task(1); err != bad || tries != 3 {
+			t.Fatal(err, tries)
+		}
+		if d := clock.Now().Sub(time.Unix(0, 0)); d != 2*time.Hour {
+			t.Fatal(d)
+		}
+	})
+	t.Run("RetryContext", func(t *testing.T) {
+		clock := &fakeClock{now: time.Unix(0, 0)}
+		tries := 0
+		task := flowmatic.RetryContext(3, func(int) time.Duration { return time.Hour }, nil,
+			func(_ context.Context, n int) (int, error) {
+				tries++
+				return n, bad
+			}, flowmatic.WithClock(clock))
+		clock.AdvanceEach(time.Hour, 2)
+		if _, err := task(context.Background(), 1); err != bad || tries != 3 {
+			t.Fatal(err, tries)
+		}
+	})
+	t.Run("Hedge", func(t *testing.T) {
+		clock := &fakeClock{now: time.Unix(0, 0)}
+		var copies atomic.Int64
+		task := flowmatic.Hedge(time.Hour, func(ctx context.Context, n int) (int, error) {
+			if copies.Add(1) == 1 {
+				<-ctx.Done()
+				return 0, ctx.Err()
+			}
+			return n, nil
+		}, flowmatic.WithClock(clock))
+		clock.AdvanceEach(time.Hour, 1)
+		if out, err := task(context.Background(), 1); out != 1 || err != nil {
+			t.Fatal(out, err)
+		}
+	})
+	t.Run("RateLimit", func(t *testing.T) {
+		clock := &fakeClock{now: time.Unix(0, 0)}
+		limiter := rate.NewLimiter(rate.Every(time.Hour), 1)
+		task := flowmatic.RateLimit(context.Background(), limiter, func(n int) (int, error) {
+			return n, nil
+		}, flowmatic.WithClock(clock))
+		clock.AdvanceEach(time.Hour, 2)
+		for i := range 3 {
+			if _, err := task(i); err != nil {
+				t.Fatal(err)
+			}
+		}
+		// the first run uses the burst without waiting
+		if d := clock.Now().Sub(time.Unix(0, 0)); d != 2*time.Hour {
+			t.Fatal(d)
+		}
+	})
+	t.Run("ManageTasksDelayedWith", func(t *testing.T) {
+		clock := &fakeClock{now: time.Unix(0, 0)}
+		task := func(_ context.Context, n int) (int, error) {
+			return n, nil
+		}
+		var at []time.Duration
+		manager := func(in, out int, err error) ([]flowmatic.Delayed[int], bool) {
+			at = append(at, clock.Now().Sub(time.Unix(0, 0)))
+			if in < 3 {
+				return []flowmatic.Delayed[int]{{Value: in + 1, Delay: time.Hour}}, true
+			}
+			return nil, true
+		}
+		clock.AdvanceEach(time.Hour, 2)
+		err := flowmatic.ManageTasksDelayedWith(context.Background(), 1, task, manager, []int{1},
+			flowmatic.WithClock(clock))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := fmt.Sprint(at); s != "[0s 1h0m0s 2h0m0s]" {
+			t.Fatal(s)
+		}
+	})
+}
@@ -0,0 +1,67 @@
+package flowmatic
+
+import (
+	"context"
+)
+
+// Collect starts numWorkers concurrent workers (or GOMAXPROCS workers if numWorkers < 1)
+// and runs task once for each input.
+// Collect returns the outputs in the order the tasks complete.
+// The first error returned by a task
+// halts further task scheduling
+// and is returned with nil outputs.
+// If a task panics during execution,
+// the panic will be caught and rethrown in the parent Goroutine.
+func Collect[Input, Output any](numWorkers int, task Task[Input, Output], inputs ...Input) ([]Output, error) {
+	var (
+		outputs  []Output
+		firstErr error
+	)
+	manager := func(_ Input, out Output, err error) ([]Input, bool) {
+		if err != nil {
+			firstErr = err
+			return nil, false
+		}
+		outputs = append(outputs, out)
+		return nil, true
+	}
+	_ = rethrow(manage(context.Background(), numWorkers, ignoreContext(task), manager, newFIFO(inputs...)))
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return outputs, nil
+}
+
+// CollectMap starts numWorkers concurrent workers (or GOMAXPROCS workers if numWorkers < 1),
+// runs task once for each input,
+// and returns the outputs of the tasks which succeeded keyed by their input.
+// Errors do not halt processing,
+// but are joined into a multierror return value
+// ordered by the position of the input that caused them,
+// alongside the map of successful outputs.
+// If an input is given more than once,
+// its task runs for each copy,
+// and the output of the copy which completes last wins.
+// If a task panics during execution,
+// the panic will be caught and rethrown in the parent Goroutine.
+func CollectMap[Input comparable, Output any](numWorkers int, task Task[Input, Output], inputs ...Input) (map[Input]Output, error) {
+	outputs := make(map[Input]Output, len(inputs))
+	var errs []indexedError
+	manager := func(in sequenced[Input], out Output, err error) ([]sequenced[Input], bool) {
+		if err != nil {
+			errs = append(errs, indexedError{in.seq, err})
+			return nil, true
+		}
+		outputs[in.val] = out
+		return nil, true
+	}
+	items := make([]sequenced[Input], len(inputs))
+	for i, in := range inputs {
+		items[i] = sequenced[Input]{i, in}
+	}
+	t := func(_ context.Context, in sequenced[Input]) (Output, error) {
+		return task(in.val)
+	}
+	_ = rethrow(manage(context.Background(), numWorkers, t, manager, newFIFO(items...)))
+	return outputs, joinIndexed(errs)
+}
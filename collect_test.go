@@ -0,0 +1,48 @@
+package flowmatic_test
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+func TestCollect(t *testing.T) {
+	square := func(n int) (int, error) {
+		if n < 0 {
+			return 0, errors.New("negative")
+		}
+		return n * n, nil
+	}
+	out, err := flowmatic.Collect(3, square, 1, 2, 3, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(out)
+	if s := fmt.Sprint(out); s != "[1 4 9 16]" {
+		t.Fatal(s)
+	}
+	out, err = flowmatic.Collect(1, square, 1, -2, 3)
+	if err == nil || out != nil {
+		t.Fatal(out, err)
+	}
+}
+
+func TestCollectMap(t *testing.T) {
+	bad := errors.New("bad")
+	task := func(s string) (int, error) {
+		if s == "" {
+			return 0, bad
+		}
+		return len(s), nil
+	}
+	m, err := flowmatic.CollectMap(2, task, "a", "bb", "", "ccc", "a")
+	if !errors.Is(err, bad) {
+		t.Fatal(err)
+	}
+	if s := fmt.Sprint(m); s != "map[a:1 bb:2 ccc:3]" {
+		t.Fatal(s)
+	}
+}
@@ -0,0 +1,42 @@
+package flowmatic
+
+import (
+	"context"
+	"errors"
+)
+
+// Completion reports why a run of ManageTasksCompletion ended.
+type Completion int8
+
+const (
+	// Drained means every queued input was processed.
+	Drained Completion = iota
+	// Stopped means processing halted before the queue was exhausted,
+	// such as because the manager returned false
+	// or an option like MaxTasks or CircuitBreaker halted it.
+	Stopped
+	// Canceled means the context was canceled before the queue was exhausted.
+	Canceled
+)
+
+// ManageTasksCompletion is like ManageTasksWith,
+// but it also reports whether the run ended because the queue was exhausted,
+// because processing was halted,
+// or because ctx was canceled.
+// If a task panics during execution,
+// the panic is rethrown as with ManageTasksWith
+// unless the OnPanic option says otherwise.
+// With the CollectAndReturn panic policy,
+// a run in which any task panicked is reported as Stopped.
+func ManageTasksCompletion[Input, Output any](ctx context.Context, numWorkers int, task CTask[Input, Output], manager Manager[Input, Output], initial []Input, opts ...Option) (Completion, error) {
+	err := manage(ctx, numWorkers, task, manager, newFIFO(initial...), opts...)
+	completion := Drained
+	switch {
+	case err == nil:
+	case ctx.Err() != nil && errors.Is(err, ctx.Err()):
+		completion = Canceled
+	default:
+		completion = Stopped
+	}
+	return completion, rethrow(err)
+}
@@ -0,0 +1,51 @@
+package flowmatic_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+func TestManageTasksCompletion(t *testing.T) {
+	task := func(_ context.Context, n int) (int, error) {
+		return n, nil
+	}
+	stopAt := -1
+	var cancel context.CancelFunc
+	manager := func(in, out int, err error) ([]int, bool) {
+		if in == 2 && cancel != nil {
+			cancel()
+		}
+		return nil, in != stopAt
+	}
+	inputs := []int{0, 1, 2, 3, 4}
+	c, err := flowmatic.ManageTasksCompletion(context.Background(), 1, task, manager, inputs)
+	if c != flowmatic.Drained || err != nil {
+		t.Fatal(c, err)
+	}
+	stopAt = 2
+	c, err = flowmatic.ManageTasksCompletion(context.Background(), 1, task, manager, inputs)
+	if c != flowmatic.Stopped || err != nil {
+		t.Fatal(c, err)
+	}
+	stopAt = -1
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	cancel = cancelFunc
+	c, err = flowmatic.ManageTasksCompletion(ctx, 1, task, manager, inputs)
+	if c != flowmatic.Canceled || err != context.Canceled {
+		t.Fatal(c, err)
+	}
+	cancel = nil
+	c, err = flowmatic.ManageTasksCompletion(context.Background(), 1, task, manager, inputs,
+		flowmatic.MaxTasks(2))
+	if c != flowmatic.Stopped || err != nil {
+		t.Fatal(c, err)
+	}
+	// a limit which leaves nothing unprocessed still drains the queue
+	c, err = flowmatic.ManageTasksCompletion(context.Background(), 1, task, manager, inputs,
+		flowmatic.MaxTasks(len(inputs)))
+	if c != flowmatic.Drained || err != nil {
+		t.Fatal(c, err)
+	}
+}
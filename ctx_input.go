@@ -0,0 +1,37 @@
+package flowmatic
+
+import (
+	"context"
+)
+
+// CtxInput pairs a task input with a context,
+// so that values such as trace spans or request IDs
+// can be carried per input rather than per run.
+type CtxInput[Input any] struct {
+	Ctx   context.Context
+	Value Input
+}
+
+// ManageTasksCtxInput is like ManageTasks,
+// but each input carries its own context.
+// Each task receives a context with the values and deadline of its input's context,
+// which is also canceled once the manager halts processing
+// or a task panics.
+// If an input's Ctx is nil,
+// its task receives a context without any values.
+// The manager receives the CtxInput of each result
+// and returns new CtxInputs to be queued.
+func ManageTasksCtxInput[Input, Output any](numWorkers int, task CTask[Input, Output], manager Manager[CtxInput[Input], Output], initial ...CtxInput[Input]) {
+	t := func(runCtx context.Context, in CtxInput[Input]) (Output, error) {
+		parent := in.Ctx
+		if parent == nil {
+			parent = runCtx
+		}
+		ctx, cancel := context.WithCancel(parent)
+		defer cancel()
+		stop := context.AfterFunc(runCtx, cancel)
+		defer stop()
+		return task(ctx, in.Value)
+	}
+	_ = rethrow(manage(context.Background(), numWorkers, t, manager, newFIFO(initial...)))
+}
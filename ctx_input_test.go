@@ -0,0 +1,57 @@
+package flowmatic_test
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+func TestManageTasksCtxInput(t *testing.T) {
+	type key struct{}
+	task := func(ctx context.Context, n int) (string, error) {
+		id, _ := ctx.Value(key{}).(string)
+		return fmt.Sprintf("%s:%d", id, n), nil
+	}
+	var seen []string
+	manager := func(in flowmatic.CtxInput[int], out string, err error) ([]flowmatic.CtxInput[int], bool) {
+		seen = append(seen, out)
+		if in.Value < 10 {
+			// children inherit the context of their parent
+			return []flowmatic.CtxInput[int]{{Ctx: in.Ctx, Value: in.Value * 10}}, true
+		}
+		return nil, true
+	}
+	ctx := func(id string) context.Context {
+		return context.WithValue(context.Background(), key{}, id)
+	}
+	flowmatic.ManageTasksCtxInput(2, task, manager,
+		flowmatic.CtxInput[int]{Ctx: ctx("a"), Value: 1},
+		flowmatic.CtxInput[int]{Ctx: ctx("b"), Value: 2},
+		flowmatic.CtxInput[int]{Value: 3},
+	)
+	slices.Sort(seen)
+	if s := fmt.Sprint(seen); s != "[:3 :30 a:1 a:10 b:2 b:20]" {
+		t.Fatal(s)
+	}
+}
+
+func TestManageTasksCtxInput_halt(t *testing.T) {
+	task := func(ctx context.Context, n int) (int, error) {
+		if n == 0 {
+			return n, nil
+		}
+		<-ctx.Done()
+		return n, ctx.Err()
+	}
+	manager := func(in flowmatic.CtxInput[int], out int, err error) ([]flowmatic.CtxInput[int], bool) {
+		return nil, in.Value != 0
+	}
+	// the running task sees the halt even though its own context is never canceled
+	flowmatic.ManageTasksCtxInput(2, task, manager,
+		flowmatic.CtxInput[int]{Ctx: context.Background(), Value: 1},
+		flowmatic.CtxInput[int]{Ctx: context.Background(), Value: 0},
+	)
+}
@@ -0,0 +1,112 @@
+package flowmatic
+
+import (
+	"context"
+)
+
+// Queue holds the inputs waiting to be dispatched by ManageTasksQueue,
+// deciding the order in which they are dispatched.
+// Its methods are only called from the Goroutine running the loop,
+// so implementations do not need to be safe for concurrent use.
+type Queue[T any] interface {
+	// Push adds an item to the queue.
+	Push(item T)
+	// Pop removes and returns the next item to dispatch,
+	// or returns false if the queue is empty.
+	Pop() (item T, ok bool)
+	// Len returns the number of items in the queue.
+	Len() int
+}
+
+// ManageTasksQueue is like ManageTasks,
+// but queued inputs are dispatched in the order given by q.
+// The initial inputs are pushed onto q before processing starts.
+// A custom Queue can implement any discipline,
+// such as taking turns between the hosts of URLs.
+func ManageTasksQueue[Input, Output any](numWorkers int, q Queue[Input], task Task[Input, Output], manager Manager[Input, Output], initial ...Input) {
+	var iq queue[Input]
+	if b, ok := q.(builtinQueue[Input]); ok {
+		iq = b.q
+	} else {
+		iq = &customQueue[Input]{q: q}
+	}
+	iq.push(initial...)
+	_ = rethrow(manage(context.Background(), numWorkers, ignoreContext(task), manager, iq))
+}
+
+// FIFOQueue returns a first-in, first-out Queue,
+// as used by ManageTasks.
+func FIFOQueue[T any]() Queue[T] {
+	return builtinQueue[T]{newFIFO[T]()}
+}
+
+// LIFOQueue returns a last-in, first-out Queue,
+// which processes the newest inputs first,
+// such as for a depth-first crawl.
+func LIFOQueue[T any]() Queue[T] {
+	return builtinQueue[T]{&lifo[T]{}}
+}
+
+// PriorityQueue returns a Queue which dispatches the least item first,
+// as used by ManageTasksPriority.
+func PriorityQueue[T any](less func(a, b T) bool) Queue[T] {
+	return builtinQueue[T]{newPriority(less)}
+}
+
+// ShuffleQueue returns a Queue which dispatches its items in random order,
+// as used by ManageTasksShuffle.
+func ShuffleQueue[T any](seed int64) Queue[T] {
+	return builtinQueue[T]{newShuffle[T](seed)}
+}
+
+// builtinQueue exports one of the package's queues as a Queue.
+// ManageTasksQueue uses the underlying queue directly.
+type builtinQueue[T any] struct {
+	q queue[T]
+}
+
+func (b builtinQueue[T]) Push(item T) { b.q.push(item) }
+
+func (b builtinQueue[T]) Pop() (item T, ok bool) {
+	item, ok = b.q.peek()
+	if ok {
+		b.q.pop()
+	}
+	return item, ok
+}
+
+func (b builtinQueue[T]) Len() int { return b.q.len() }
+
+// customQueue adapts a Queue to the loop,
+// which peeks at an item before it knows whether a worker will take it.
+// The popped item is held until the loop dispatches it.
+type customQueue[T any] struct {
+	q    Queue[T]
+	head T
+	held bool
+}
+
+func (c *customQueue[T]) push(items ...T) {
+	for _, item := range items {
+		c.q.Push(item)
+	}
+}
+
+func (c *customQueue[T]) peek() (T, bool) {
+	if !c.held {
+		c.head, c.held = c.q.Pop()
+	}
+	return c.head, c.held
+}
+
+func (c *customQueue[T]) pop() {
+	var zero T
+	c.head, c.held = zero, false
+}
+
+func (c *customQueue[T]) len() int {
+	if c.held {
+		return c.q.Len() + 1
+	}
+	return c.q.Len()
+}
@@ -0,0 +1,65 @@
+package flowmatic_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+// evensFirst is a Queue which dispatches even numbers before odd numbers.
+type evensFirst struct {
+	evens, odds []int
+}
+
+func (q *evensFirst) Push(n int) {
+	if n%2 == 0 {
+		q.evens = append(q.evens, n)
+	} else {
+		q.odds = append(q.odds, n)
+	}
+}
+
+func (q *evensFirst) Pop() (int, bool) {
+	for _, s := range []*[]int{&q.evens, &q.odds} {
+		if len(*s) > 0 {
+			n := (*s)[0]
+			*s = (*s)[1:]
+			return n, true
+		}
+	}
+	return 0, false
+}
+
+func (q *evensFirst) Len() int { return len(q.evens) + len(q.odds) }
+
+func TestManageTasksQueue(t *testing.T) {
+	task := func(n int) (int, error) {
+		return n, nil
+	}
+	order := func(q flowmatic.Queue[int]) string {
+		var seen []int
+		manager := func(in, out int, err error) ([]int, bool) {
+			seen = append(seen, in)
+			return nil, true
+		}
+		flowmatic.ManageTasksQueue(1, q, task, manager, 1, 2, 3, 4, 5, 6)
+		return fmt.Sprint(seen)
+	}
+	for _, tc := range []struct {
+		name string
+		q    flowmatic.Queue[int]
+		want string
+	}{
+		{"fifo", flowmatic.FIFOQueue[int](), "[1 2 3 4 5 6]"},
+		{"lifo", flowmatic.LIFOQueue[int](), "[6 5 4 3 2 1]"},
+		{"priority", flowmatic.PriorityQueue(func(a, b int) bool { return a > b }), "[6 5 4 3 2 1]"},
+		{"custom", &evensFirst{}, "[2 4 6 1 3 5]"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := order(tc.q); got != tc.want {
+				t.Fatal(got)
+			}
+		})
+	}
+}
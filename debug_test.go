@@ -0,0 +1,113 @@
+package flowmatic_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+// FuzzManageTasksWith drives the loop with tasks and managers
+// whose behavior is chosen by the fuzzer,
+// checking the bookkeeping of the loop with the Debug option
+// and the results seen by the manager.
+func FuzzManageTasksWith(f *testing.F) {
+	f.Add([]byte{4, 0, 1, 2, 3, 4, 5, 6, 7, 8, 9})
+	f.Add([]byte{1, 1, 14, 7, 255, 3})
+	f.Add([]byte{8, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2})
+	f.Add([]byte{3, 5, 100, 21, 0, 0, 200, 42})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if len(data) < 3 {
+			return
+		}
+		numWorkers := int(data[0]%8) + 1
+		flags := data[1]
+		data = data[2:]
+		opts := []flowmatic.Option{flowmatic.Debug(true)}
+		if flags&1 != 0 {
+			opts = append(opts, flowmatic.Synchronous())
+		}
+		if flags&2 != 0 {
+			opts = append(opts, flowmatic.OutputBuffer(int(flags>>4)))
+		}
+		if flags&4 != 0 {
+			opts = append(opts, flowmatic.MaxTasks(len(data)))
+		}
+		errTask := errors.New("task failed")
+		// each input is an index into data
+		task := func(_ context.Context, i int) (int, error) {
+			if data[i]%7 == 0 {
+				return 0, errTask
+			}
+			return int(data[i]), nil
+		}
+		queued, seen := len(data), 0
+		halted := false
+		counts := make([]int, len(data))
+		manager := func(i, out int, err error) ([]int, bool) {
+			seen++
+			counts[i]++
+			if (err != nil) != (data[i]%7 == 0) || err == nil && out != int(data[i]) {
+				t.Fatalf("wrong result for %d: %d, %v", i, out, err)
+			}
+			if data[i] == 255 {
+				halted = true
+				return nil, false
+			}
+			// expand each input at most once, to a bounded depth
+			var next []int
+			if counts[i] == 1 {
+				for j := range int(data[i] % 3) {
+					if k := 2*i + j + 1; k < len(data) {
+						next = append(next, k)
+					}
+				}
+			}
+			queued += len(next)
+			return next, true
+		}
+		initial := make([]int, len(data))
+		for i := range initial {
+			initial[i] = i
+		}
+		err := flowmatic.ManageTasksWith(context.Background(), numWorkers, task, manager, initial, opts...)
+		switch {
+		case err != nil:
+			t.Fatal(err)
+		case halted:
+			if seen > queued {
+				t.Fatal(seen, queued)
+			}
+		case flags&4 != 0:
+			if seen != min(queued, len(data)) {
+				t.Fatal(seen, queued, len(data))
+			}
+		default:
+			if seen != queued {
+				t.Fatal(seen, queued)
+			}
+		}
+	})
+}
+
+func TestDebug(t *testing.T) {
+	task := func(_ context.Context, n int) (int, error) {
+		return n, nil
+	}
+	manager := func(in, out int, err error) ([]int, bool) {
+		if in < 50 {
+			return []int{2*in + 1, 2*in + 2}, true
+		}
+		return nil, true
+	}
+	for _, synchronous := range []bool{false, true} {
+		opts := []flowmatic.Option{flowmatic.Debug(true)}
+		if synchronous {
+			opts = append(opts, flowmatic.Synchronous())
+		}
+		if err := flowmatic.ManageTasksWith(context.Background(), 4, task, manager, []int{0}, opts...); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package flowmatic
+
+import (
+	"context"
+	"time"
+)
+
+// Delayed is a task input which should not be dispatched
+// until Delay has passed since it was queued.
+type Delayed[Input any] struct {
+	Value Input
+	Delay time.Duration
+}
+
+// ManageTasksDelayed is like ManageTasks,
+// but the manager returns Delayed inputs,
+// which are held back until their delay has passed
+// and then queued behind any inputs which are already waiting.
+// This allows retries with backoff to be scheduled by the manager
+// without sleeping inside a task and blocking a worker.
+// Inputs without a positive delay are queued immediately.
+// Initial inputs are not delayed.
+func ManageTasksDelayed[Input, Output any](numWorkers int, task Task[Input, Output], manager func(Input, Output, error) ([]Delayed[Input], bool), initial ...Input) {
+	_ = ManageTasksDelayedWith(context.Background(), numWorkers, ignoreContext(task), manager, initial)
+}
+
+// ManageTasksDelayedWith is like ManageTasksDelayed,
+// but it is configured with options like ManageTasksWith.
+// The Clock set with WithClock also times the delays.
+func ManageTasksDelayedWith[Input, Output any](ctx context.Context, numWorkers int, task CTask[Input, Output], manager func(Input, Output, error) ([]Delayed[Input], bool), initial []Input, opts ...Option) error {
+	o := buildOptions(opts)
+	q := newDelays[Input](o.clock())
+	for _, in := range initial {
+		q.push(Delayed[Input]{Value: in})
+	}
+	m := func(in Delayed[Input], out Output, err error) ([]Delayed[Input], bool) {
+		return manager(in.Value, out, err)
+	}
+	t := func(ctx context.Context, in Delayed[Input]) (Output, error) {
+		return task(ctx, in.Value)
+	}
+	return rethrow(manage(ctx, numWorkers, t, m, q, opts...))
+}
+
+// delays is a first-in, first-out queue of Delayed items
+// which holds back each item until its delay has passed.
+type delays[T any] struct {
+	ready   *fifo[Delayed[T]]
+	waiting *priority[timed[T]]
+	seq     int
+	clock   Clock
+	// wakeAt is when wakeC fires.
+	wakeAt time.Time
+	wakeC  <-chan time.Time
+}
+
+// timed is a Delayed item and the time when it is ready.
+// seq breaks ties so items with the same ready time stay in order.
+type timed[T any] struct {
+	at   time.Time
+	seq  int
+	item Delayed[T]
+}
+
+func newDelays[T any](c Clock) *delays[T] {
+	return &delays[T]{
+		clock: c,
+		ready: newFIFO[Delayed[T]](),
+		waiting: newPriority(func(a, b timed[T]) bool {
+			if a.at.Equal(b.at) {
+				return a.seq < b.seq
+			}
+			return a.at.Before(b.at)
+		}),
+	}
+}
+
+func (q *delays[T]) push(items ...Delayed[T]) {
+	now := q.clock.Now()
+	for _, item := range items {
+		if item.Delay <= 0 {
+			q.ready.push(item)
+			continue
+		}
+		q.waiting.push(timed[T]{now.Add(item.Delay), q.seq, item})
+		q.seq++
+	}
+}
+
+// promote moves the items whose delay has passed into the ready queue.
+func (q *delays[T]) promote() {
+	now := q.clock.Now()
+	for {
+		t, ok := q.waiting.peek()
+		if !ok || t.at.After(now) {
+			return
+		}
+		q.waiting.pop()
+		q.ready.push(t.item)
+	}
+}
+
+func (q *delays[T]) peek() (Delayed[T], bool) {
+	q.promote()
+	return q.ready.peek()
+}
+
+func (q *delays[T]) pop()     { q.ready.pop() }
+func (q *delays[T]) len() int { return q.ready.len() + q.waiting.len() }
+
+// wait returns a channel which fires when the next held back item is ready,
+// or nil if no items are held back.
+func (q *delays[T]) wait() <-chan time.Time {
+	t, ok := q.waiting.peek()
+	if !ok {
+		return nil
+	}
+	if !t.at.Equal(q.wakeAt) {
+		q.wakeAt = t.at
+		q.wakeC = q.clock.After(t.at.Sub(q.clock.Now()))
+	}
+	return q.wakeC
+}
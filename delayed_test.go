@@ -0,0 +1,71 @@
+package flowmatic_test
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+func TestManageTasksDelayed(t *testing.T) {
+	bad := errors.New("bad")
+	var (
+		mu    sync.Mutex
+		tries = map[int]int{}
+	)
+	task := func(n int) (int, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		tries[n]++
+		if n == 1 && tries[n] < 3 {
+			return 0, bad
+		}
+		return n, nil
+	}
+	var order []int
+	var last time.Time
+	manager := func(in, out int, err error) ([]flowmatic.Delayed[int], bool) {
+		if err != nil {
+			if !last.IsZero() && time.Since(last) < 10*time.Millisecond {
+				t.Errorf("retried too soon: %v", time.Since(last))
+			}
+			last = time.Now()
+			return []flowmatic.Delayed[int]{{Value: in, Delay: 10 * time.Millisecond}}, true
+		}
+		order = append(order, out)
+		if in == 2 {
+			return []flowmatic.Delayed[int]{{Value: 4}, {Value: 5, Delay: time.Millisecond}}, true
+		}
+		return nil, true
+	}
+	start := time.Now()
+	flowmatic.ManageTasksDelayed(1, task, manager, 1, 2, 3)
+	if d := time.Since(start); d < 20*time.Millisecond {
+		t.Fatal(d)
+	}
+	if s := fmt.Sprint(order); s != "[2 3 4 5 1]" {
+		t.Fatal(s)
+	}
+}
+
+func TestManageTasksDelayed_order(t *testing.T) {
+	// a delayed input is overtaken by inputs queued after it
+	task := func(n int) (int, error) {
+		return n, nil
+	}
+	var order []int
+	manager := func(in, out int, err error) ([]flowmatic.Delayed[int], bool) {
+		order = append(order, in)
+		if in == 1 {
+			return []flowmatic.Delayed[int]{{Value: 10, Delay: 5 * time.Millisecond}, {Value: 11}}, true
+		}
+		return nil, true
+	}
+	flowmatic.ManageTasksDelayed(1, task, manager, 1, 2)
+	if s := fmt.Sprint(order); s != "[1 2 11 10]" {
+		t.Fatal(s)
+	}
+}
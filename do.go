@@ -8,11 +8,26 @@ import (
 // Do runs each task concurrently
 // and waits for them all to finish.
 // Errors returned by tasks do not cancel execution,
-// but are joined into a multierror return value.
+// but are joined into a multierror return value,
+// ordered by the position of the task that returned them.
 // If a task panics during execution,
-// a panic will be caught and rethrown in the parent Goroutine.
+// a panic will be caught and rethrown in the parent Goroutine
+// once all the tasks have finished.
+// If more than one task panics,
+// exactly one panic is rethrown,
+// that of the first of those tasks in the argument list.
 func Do(tasks ...func() error) error {
+	return errors.Join(DoIndexed(tasks...)...)
+}
+
+// DoIndexed is like Do,
+// but instead of joining the errors returned by tasks,
+// it returns them in a slice aligned with tasks,
+// so errs[i] is the error returned by tasks[i].
+// If every task succeeds, DoIndexed returns nil.
+func DoIndexed(tasks ...func() error) (errs []error) {
 	type result struct {
+		i     int
 		err   error
 		panic any
 	}
@@ -21,16 +36,15 @@ func Do(tasks ...func() error) error {
 	errch := make(chan result, len(tasks))
 
 	wg.Add(len(tasks))
-	for i := range tasks {
-		fn := tasks[i]
+	for i, fn := range tasks {
 		go func() {
 			defer wg.Done()
 			defer func() {
 				if panicVal := recover(); panicVal != nil {
-					errch <- result{panic: panicVal}
+					errch <- result{i: i, panic: panicVal}
 				}
 			}()
-			errch <- result{err: fn()}
+			errch <- result{i: i, err: fn()}
 		}()
 	}
 	go func() {
@@ -40,20 +54,23 @@ func Do(tasks ...func() error) error {
 
 	var (
 		panicVal any
-		errs     []error
+		panicPos = len(tasks)
 	)
 	for res := range errch {
 		switch {
-		case res.err == nil && res.panic == nil:
-			continue
 		case res.panic != nil:
-			panicVal = res.panic
+			if res.i < panicPos {
+				panicVal, panicPos = res.panic, res.i
+			}
 		case res.err != nil:
-			errs = append(errs, res.err)
+			if errs == nil {
+				errs = make([]error, len(tasks))
+			}
+			errs[res.i] = res.err
 		}
 	}
-	if panicVal != nil {
+	if panicPos < len(tasks) {
 		panic(panicVal)
 	}
-	return errors.Join(errs...)
+	return errs
 }
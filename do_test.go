@@ -21,3 +21,23 @@ func TestDo_err(t *testing.T) {
 		t.Fatal(errs)
 	}
 }
+
+func TestDoIndexed(t *testing.T) {
+	a := errors.New("a")
+	b := errors.New("b")
+	errs := flowmatic.DoIndexed(
+		func() error { return a },
+		func() error { return nil },
+		func() error { return b },
+	)
+	if len(errs) != 3 || errs[0] != a || errs[1] != nil || errs[2] != b {
+		t.Fatal(errs)
+	}
+	errs = flowmatic.DoIndexed(
+		func() error { return nil },
+		func() error { return nil },
+	)
+	if errs != nil {
+		t.Fatal(errs)
+	}
+}
@@ -9,8 +9,36 @@
 //	Each   Same        No                 No
 //	Map    Same        On error           Yes
 //
-// ManageTasks and TaskPool allow for advanced concurrency patterns.
+// ManageTasks, TaskStream, Pool, and TaskPool allow for advanced concurrency patterns.
+//
+// Functions which take a number of workers
+// use GOMAXPROCS workers if the number is less than 1.
+// That suits tasks which are bound by the CPU,
+// but tasks which mostly wait on the network or disk
+// usually want many more workers; see IOWorkers.
+//
+// Functions which join the errors of several tasks into a multierror
+// order them by the position of the task or input that returned them,
+// never by the order in which the tasks finished,
+// so the joined error is the same from run to run.
+// The exception is ManageTasksWith with the CollectAndReturn panic policy,
+// since inputs queued by the manager have no fixed position.
 package flowmatic
 
+import (
+	"runtime"
+)
+
 // MaxProcs means use GOMAXPROCS workers when doing tasks.
+// It suits tasks which are bound by the CPU.
 const MaxProcs = -1
+
+// IOWorkers returns a number of workers suited to tasks
+// which spend most of their time waiting on I/O,
+// such as network requests.
+// It is 16 workers per GOMAXPROCS, but at least 64.
+// The right number depends on what the tasks are waiting for,
+// so treat it as a starting point rather than an optimum.
+func IOWorkers() int {
+	return max(64, 16*runtime.GOMAXPROCS(0))
+}
@@ -0,0 +1,14 @@
+package flowmatic_test
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+func TestIOWorkers(t *testing.T) {
+	if n := flowmatic.IOWorkers(); n < 64 || n < runtime.GOMAXPROCS(0) {
+		t.Fatal(n)
+	}
+}
@@ -1,13 +1,18 @@
 package flowmatic
 
 import (
+	"context"
 	"errors"
+	"iter"
 )
 
 // Each starts numWorkers concurrent workers (or GOMAXPROCS workers if numWorkers < 1)
 // and processes each item as a task.
 // Errors returned by a task do not halt execution,
-// but are joined into a multierror return value.
+// but are joined into a multierror return value,
+// ordered by the position of the item that caused them,
+// so every item is processed and every failure is reported.
+// To halt on the first error instead, use EachCancel.
 // If a task panics during execution,
 // the panic will be caught and rethrown in the parent Goroutine.
 func Each[Input any](numWorkers int, items []Input, task func(Input) error) error {
@@ -16,10 +21,148 @@ func Each[Input any](numWorkers int, items []Input, task func(Input) error) erro
 	})
 }
 
+// EachCancel starts numWorkers concurrent workers (or GOMAXPROCS workers if numWorkers < 1)
+// and processes each item as a task.
+// Each task receives a child context.
+// The first error returned by a task
+// cancels the child context,
+// halts further task scheduling,
+// and is returned once the running tasks have finished.
+// If ctx is canceled, EachCancel returns ctx.Err().
+// If a task panics during execution,
+// the panic will be caught and rethrown in the parent Goroutine.
+func EachCancel[Input any](ctx context.Context, numWorkers int, items []Input, task func(context.Context, Input) error) error {
+	type void struct{}
+	var firstErr error
+	manager := func(_ Input, _ void, err error) ([]Input, bool) {
+		if err != nil {
+			firstErr = err
+			return nil, false
+		}
+		return nil, true
+	}
+	err := rethrow(manage(ctx, numWorkers, func(ctx context.Context, item Input) (void, error) {
+		return void{}, task(ctx, item)
+	}, manager, newFIFO(items...)))
+	if firstErr != nil {
+		return firstErr
+	}
+	return err
+}
+
+// EachProgress reports how far EachContext got.
+type EachProgress struct {
+	// Done holds the indices of the items whose tasks succeeded,
+	// in increasing order.
+	Done []int
+	// Err is ctx.Err() if the context was canceled.
+	// Otherwise, it joins the errors returned by tasks
+	// ordered by the position of the item that caused them.
+	Err error
+}
+
+// EachContext is like Each,
+// but each task receives a child context,
+// and once ctx is canceled no more tasks are started.
+// Tasks which are already running see the cancellation through their context,
+// and EachContext waits for them to return.
+// The returned EachProgress says which items were processed successfully,
+// including by tasks which succeeded after the cancellation,
+// so that a later call with the rest of the items can resume the work.
+// If a task panics during execution,
+// the panic will be caught and rethrown in the parent Goroutine.
+func EachContext[Input any](ctx context.Context, numWorkers int, items []Input, task func(context.Context, Input) error) EachProgress {
+	type void struct{}
+	// each slot is written only by the task for its item,
+	// and read only after every task has returned
+	succeeded := make([]bool, len(items))
+	errs := make([]error, len(items))
+	positions := make([]int, len(items))
+	for i := range positions {
+		positions[i] = i
+	}
+	err := rethrow(manage(ctx, numWorkers, func(ctx context.Context, pos int) (void, error) {
+		if err := task(ctx, items[pos]); err != nil {
+			errs[pos] = err
+		} else {
+			succeeded[pos] = true
+		}
+		return void{}, nil
+	}, nil, newFIFO(positions...)))
+	var p EachProgress
+	for i, ok := range succeeded {
+		if ok {
+			p.Done = append(p.Done, i)
+		}
+	}
+	p.Err = err
+	if err == nil {
+		p.Err = errors.Join(errs...)
+	}
+	return p
+}
+
+// EachSeq is like Each,
+// but it processes the items of seq.
+// Errors are ordered by the position in seq of the item that caused them.
+// Items are pulled from seq only as workers become free to process them,
+// so seq is never read far ahead of the running tasks.
+// If a task panics during execution,
+// no more items are pulled
+// and the panic will be caught and rethrown in the parent Goroutine.
+func EachSeq[Input any](numWorkers int, seq iter.Seq[Input], task func(Input) error) error {
+	type void struct{}
+	var errs []indexedError
+	manager := func(in sequenced[Input], _ void, err error) ([]sequenced[Input], bool) {
+		if err != nil {
+			errs = append(errs, indexedError{in.seq, err})
+		}
+		return nil, true
+	}
+	next, stop := iter.Pull(seq)
+	defer stop()
+	pos := 0
+	_ = rethrow(manage(context.Background(), numWorkers, func(_ context.Context, item sequenced[Input]) (void, error) {
+		return void{}, task(item.val)
+	}, manager, newPull(func() (sequenced[Input], bool) {
+		item, ok := next()
+		s := sequenced[Input]{pos, item}
+		pos++
+		return s, ok
+	})))
+	return joinIndexed(errs)
+}
+
+// EachSeqCancel is like EachCancel,
+// but it processes the items of seq.
+// Items are pulled from seq only as workers become free to process them.
+// No more items are pulled once a task returns an error or ctx is canceled.
+func EachSeqCancel[Input any](ctx context.Context, numWorkers int, seq iter.Seq[Input], task func(context.Context, Input) error) error {
+	type void struct{}
+	var firstErr error
+	manager := func(_ Input, _ void, err error) ([]Input, bool) {
+		if err != nil {
+			firstErr = err
+			return nil, false
+		}
+		return nil, true
+	}
+	next, stop := iter.Pull(seq)
+	defer stop()
+	err := rethrow(manage(ctx, numWorkers, func(ctx context.Context, item Input) (void, error) {
+		return void{}, task(ctx, item)
+	}, manager, newPull(next)))
+	if firstErr != nil {
+		return firstErr
+	}
+	return err
+}
+
 // eachN starts numWorkers concurrent workers (or GOMAXPROCS workers if numWorkers < 1)
 // and starts a task for each number from 0 to numItems.
 // Errors returned by a task do not halt execution,
-// but are joined into a multierror return value.
+// but are joined into a multierror return value,
+// ordered by the number of the task that returned them.
 // If a task panics during execution,
 // the panic will be caught and rethrown in the parent Goroutine.
 func eachN(numWorkers, numItems int, task func(int) error) error {
@@ -27,10 +170,8 @@ func eachN(numWorkers, numItems int, task func(int) error) error {
 	inch, ouch := TaskPool(numWorkers, func(pos int) (void, error) {
 		return void{}, task(pos)
 	})
-	var (
-		panicVal any
-		errs     []error
-	)
+	var panicVal any
+	errs := make([]error, numItems)
 	_ = Do(
 		func() error {
 			for i := 0; i < numItems; i++ {
@@ -44,9 +185,7 @@ func eachN(numWorkers, numItems int, task func(int) error) error {
 				if r.Panic != nil && panicVal == nil {
 					panicVal = r.Panic
 				}
-				if r.Err != nil {
-					errs = append(errs, r.Err)
-				}
+				errs[r.In] = r.Err
 			}
 			return nil
 		})
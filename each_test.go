@@ -1,8 +1,14 @@
 package flowmatic_test
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"runtime"
+	"slices"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/carlmjohnson/flowmatic"
 )
@@ -10,7 +16,9 @@ import (
 func TestEach_err(t *testing.T) {
 	a := errors.New("a")
 	b := errors.New("b")
+	var ran atomic.Int64
 	errs := flowmatic.Each(1, []int{1, 2, 3}, func(i int) error {
+		ran.Add(1)
 		switch i {
 		case 1:
 			return a
@@ -26,4 +34,163 @@ func TestEach_err(t *testing.T) {
 	if !errors.Is(errs, b) {
 		t.Fatal(errs)
 	}
+	// failures do not stop the remaining items
+	if n := ran.Load(); n != 3 {
+		t.Fatal(n)
+	}
+}
+
+func TestEachCancel(t *testing.T) {
+	before := runtime.NumGoroutine()
+	bad := errors.New("bad")
+	var started, canceled atomic.Int64
+	items := make([]int, 1000)
+	for i := range items {
+		items[i] = i
+	}
+	err := flowmatic.EachCancel(context.Background(), 4, items, func(ctx context.Context, i int) error {
+		started.Add(1)
+		if i == 2 {
+			return bad
+		}
+		if !sleepFor(ctx, time.Second) {
+			canceled.Add(1)
+		}
+		return nil
+	})
+	if err != bad {
+		t.Fatal(err)
+	}
+	if n := started.Load(); n > 10 {
+		t.Fatal(n)
+	}
+	if canceled.Load() == 0 {
+		t.Fatal("running tasks should see cancelation")
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Fatal(before, after)
+	}
+}
+
+func TestEachSeq(t *testing.T) {
+	a := errors.New("a")
+	var sum atomic.Int64
+	err := flowmatic.EachSeq(3, slices.Values([]int{1, 2, 3, 4}), func(i int) error {
+		sum.Add(int64(i))
+		if i == 2 {
+			return a
+		}
+		return nil
+	})
+	if !errors.Is(err, a) {
+		t.Fatal(err)
+	}
+	if n := sum.Load(); n != 10 {
+		t.Fatal(n)
+	}
+}
+
+func TestEachSeqCancel(t *testing.T) {
+	before := runtime.NumGoroutine()
+	bad := errors.New("bad")
+	var pulled, running, maxAhead atomic.Int64
+	// an endless sequence to make sure it is read lazily
+	seq := func(yield func(int) bool) {
+		for i := 0; ; i++ {
+			if ahead := pulled.Add(1) - running.Load(); ahead > maxAhead.Load() {
+				maxAhead.Store(ahead)
+			}
+			if !yield(i) {
+				return
+			}
+		}
+	}
+	err := flowmatic.EachSeqCancel(context.Background(), 2, seq, func(ctx context.Context, i int) error {
+		running.Add(1)
+		if i == 20 {
+			return bad
+		}
+		time.Sleep(time.Millisecond)
+		return nil
+	})
+	if err != bad {
+		t.Fatal(err)
+	}
+	if n := pulled.Load(); n > 25 {
+		t.Fatal("pulled too many items:", n)
+	}
+	if n := maxAhead.Load(); n > 4 {
+		t.Fatal("read too far ahead:", n)
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Fatal(before, after)
+	}
+}
+
+func TestEachSeqCancel_noExtraPull(t *testing.T) {
+	bad := errors.New("bad")
+	for _, stopWith := range []string{"cancel", "error"} {
+		var pulled atomic.Int64
+		seq := func(yield func(int) bool) {
+			for i := 0; ; i++ {
+				pulled.Add(1)
+				if !yield(i) {
+					return
+				}
+			}
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		err := flowmatic.EachSeqCancel(ctx, 1, seq, func(ctx context.Context, i int) error {
+			time.Sleep(10 * time.Millisecond)
+			// nothing is pulled while the only worker is busy
+			if n := pulled.Load(); n != 1 {
+				t.Errorf("%s: pulled %d items while running the first", stopWith, n)
+			}
+			if stopWith == "cancel" {
+				cancel()
+				return nil
+			}
+			return bad
+		})
+		cancel()
+		if stopWith == "error" && err != bad || stopWith == "cancel" && err != context.Canceled {
+			t.Fatal(stopWith, err)
+		}
+		if n := pulled.Load(); n != 1 {
+			t.Fatalf("%s: pulled %d items", stopWith, n)
+		}
+	}
+}
+
+func TestEachContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	items := make([]int, 20)
+	for i := range items {
+		items[i] = i
+	}
+	p := flowmatic.EachContext(ctx, 1, items, func(ctx context.Context, i int) error {
+		if i == 5 {
+			// succeeds after canceling
+			cancel()
+			return nil
+		}
+		return ctx.Err()
+	})
+	if !errors.Is(p.Err, context.Canceled) {
+		t.Fatal(p.Err)
+	}
+	if s := fmt.Sprint(p.Done); s != "[0 1 2 3 4 5]" {
+		t.Fatal(s)
+	}
+
+	p = flowmatic.EachContext(context.Background(), 4, items, func(ctx context.Context, i int) error {
+		if i%5 == 0 {
+			return fmt.Errorf("e%d", i)
+		}
+		return nil
+	})
+	if p.Err == nil || p.Err.Error() != "e0\ne5\ne10\ne15" || len(p.Done) != 16 || p.Done[0] != 1 {
+		t.Fatal(p)
+	}
 }
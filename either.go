@@ -0,0 +1,34 @@
+package flowmatic
+
+// Either holds either a value of type A or a value of type B.
+// It can be used as the Input or Output type of a task
+// to mix two kinds of work in a single run,
+// such as fetching pages and parsing them.
+// The zero value holds the zero value of A.
+type Either[A, B any] struct {
+	a   A
+	b   B
+	isB bool
+}
+
+// Left returns an Either holding a.
+func Left[A, B any](a A) Either[A, B] {
+	return Either[A, B]{a: a}
+}
+
+// Right returns an Either holding b.
+func Right[A, B any](b B) Either[A, B] {
+	return Either[A, B]{b: b, isB: true}
+}
+
+// Left returns the value of type A and true
+// if e holds a value of type A.
+func (e Either[A, B]) Left() (a A, ok bool) {
+	return e.a, !e.isB
+}
+
+// Right returns the value of type B and true
+// if e holds a value of type B.
+func (e Either[A, B]) Right() (b B, ok bool) {
+	return e.b, e.isB
+}
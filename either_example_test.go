@@ -0,0 +1,76 @@
+package flowmatic_test
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+func ExampleEither() {
+	// Example site to crawl
+	site := map[string]string{
+		"/":        "<a>/a.html</a>",
+		"/a.html":  "<a>/b1.html</a> <a>/b2.html</a>",
+		"/b1.html": "<a>/</a>",
+		"/b2.html": "no links",
+	}
+	type page struct{ url, html string }
+
+	// Jobs either fetch a URL or parse a page.
+	// Fetching produces HTML, and parsing produces links.
+	type (
+		job    = flowmatic.Either[string, page]
+		output = flowmatic.Either[string, []string]
+	)
+	task := func(j job) (output, error) {
+		if url, ok := j.Left(); ok {
+			html, ok := site[url]
+			if !ok {
+				return output{}, fmt.Errorf("not found: %s", url)
+			}
+			return flowmatic.Left[string, []string](html), nil
+		}
+		p, _ := j.Right()
+		var links []string
+		for _, field := range strings.Fields(p.html) {
+			if link, ok := strings.CutPrefix(field, "<a>"); ok {
+				links = append(links, strings.TrimSuffix(link, "</a>"))
+			}
+		}
+		return flowmatic.Right[string](links), nil
+	}
+
+	// The manager turns fetched pages into parse jobs
+	// and unseen links into fetch jobs.
+	seen := map[string]bool{"/": true}
+	var parsed []string
+	manager := func(j job, out output, err error) ([]job, bool) {
+		if err != nil {
+			panic(err)
+		}
+		if html, ok := out.Left(); ok {
+			url, _ := j.Left()
+			return []job{flowmatic.Right[string](page{url, html})}, true
+		}
+		p, _ := j.Right()
+		parsed = append(parsed, p.url)
+		links, _ := out.Right()
+		var jobs []job
+		for _, link := range links {
+			if !seen[link] {
+				seen[link] = true
+				jobs = append(jobs, flowmatic.Left[string, page](link))
+			}
+		}
+		return jobs, true
+	}
+
+	flowmatic.ManageTasks(4, task, manager, flowmatic.Left[string, page]("/"))
+
+	slices.Sort(parsed)
+	fmt.Println(parsed)
+	// Output:
+	// [/ /a.html /b1.html /b2.html]
+}
@@ -0,0 +1,117 @@
+package flowmatic
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var (
+	// ErrStopped is returned by ManageTasksErr when the manager halts processing.
+	ErrStopped = errors.New("flowmatic: manager halted processing")
+	// ErrTaskTimeout is returned by a task wrapped with WithTimeout
+	// which runs longer than its timeout.
+	// It wraps context.DeadlineExceeded.
+	ErrTaskTimeout = fmt.Errorf("flowmatic: task timed out: %w", context.DeadlineExceeded)
+	// ErrCircuitOpen is returned by ManageTasksWith
+	// when the CircuitBreaker option halts processing.
+	ErrCircuitOpen = errors.New("flowmatic: too many consecutive task errors")
+	// ErrIdle is returned by ManageTasksChanWith
+	// when the IdleTimeout option halts processing.
+	ErrIdle = errors.New("flowmatic: idle timeout")
+	// ErrSkip can be returned by a task
+	// to say that there is nothing to do for its input.
+	// The task management loop does not give the result to the manager,
+	// but calls the function set with OnSkip, if any.
+	ErrSkip = errors.New("flowmatic: skip input")
+	// ErrPossibleStall is returned by ManageTasksWith
+	// when the StallTimeout option halts processing.
+	ErrPossibleStall = errors.New("flowmatic: all workers stalled")
+	// ErrInputCanceled is the cause of the context of a task
+	// canceled with Cancels.Cancel,
+	// and it is wrapped by the error of a task whose key was canceled
+	// before it started.
+	ErrInputCanceled = errors.New("flowmatic: input canceled")
+	// ErrNoRoute is returned by a task made with Route
+	// when its TaskRouter has no task for an input.
+	ErrNoRoute = errors.New("flowmatic: no task for input")
+	// ErrReorderOverflow is returned by ManageTasksOrderedWith
+	// when the ReorderBuffer option overflows with ErrorOnOverflow.
+	ErrReorderOverflow = errors.New("flowmatic: reorder buffer overflow")
+)
+
+// PanicError is an error wrapping a value recovered from a panicking task.
+type PanicError struct {
+	// Input is the input of the panicking task,
+	// if it is known.
+	Input any
+	// Value is the value passed to panic.
+	Value any
+	// Stack is the stack trace of the panicking Goroutine.
+	Stack []byte
+	// manager is set if the manager panicked rather than a task.
+	manager bool
+}
+
+// Error returns the panic value and input, if known,
+// followed by the stack trace, if any.
+func (pe *PanicError) Error() string {
+	msg := fmt.Sprintf("panic: %v", pe.Value)
+	if pe.Input != nil {
+		msg = fmt.Sprintf("panic: %v (input: %v)", pe.Value, pe.Input)
+	}
+	if len(pe.Stack) > 0 {
+		msg += "\n\n" + string(pe.Stack)
+	}
+	return msg
+}
+
+// Unwrap returns Value if it is an error.
+func (pe *PanicError) Unwrap() error {
+	err, _ := pe.Value.(error)
+	return err
+}
+
+// MultiPanic is a panic value combining the panics of several tasks.
+// See CollectPanics.
+type MultiPanic []*PanicError
+
+func (mp MultiPanic) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d panics:", len(mp))
+	for _, pe := range mp {
+		fmt.Fprintf(&sb, "\n\n%v\n%s", pe.Value, pe.Stack)
+	}
+	return sb.String()
+}
+
+// Unwrap returns the PanicErrors combined in mp.
+func (mp MultiPanic) Unwrap() []error {
+	errs := make([]error, len(mp))
+	for i, pe := range mp {
+		errs[i] = pe
+	}
+	return errs
+}
+
+// indexedError is an error with the position of the input or task that returned it.
+type indexedError struct {
+	pos int
+	err error
+}
+
+// joinIndexed joins errs ordered by position,
+// whatever order they were collected in.
+func joinIndexed(errs []indexedError) error {
+	slices.SortFunc(errs, func(a, b indexedError) int {
+		return cmp.Compare(a.pos, b.pos)
+	})
+	joined := make([]error, len(errs))
+	for i, ie := range errs {
+		joined[i] = ie.err
+	}
+	return errors.Join(joined...)
+}
@@ -0,0 +1,70 @@
+package flowmatic_test
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+func TestJoinedErrorOrder(t *testing.T) {
+	const n = 5
+	want := "e0\ne1\ne2\ne3\ne4"
+	// later items fail first
+	fail := func(i int) error {
+		time.Sleep(time.Duration(n-i) * time.Millisecond)
+		return fmt.Errorf("e%d", i)
+	}
+	items := []int{0, 1, 2, 3, 4}
+	funcs := make([]func() error, n)
+	cfuncs := make([]func(context.Context) error, n)
+	vfuncs := make([]func(context.Context) (int, error), n)
+	for i := range n {
+		funcs[i] = func() error { return fail(i) }
+		cfuncs[i] = func(context.Context) error { return fail(i) }
+		vfuncs[i] = func(context.Context) (int, error) { return 0, fail(i) }
+	}
+	ctx := context.Background()
+	for name, run := range map[string]func() error{
+		"Do":      func() error { return flowmatic.Do(funcs...) },
+		"All":     func() error { return flowmatic.All(ctx, cfuncs...) },
+		"Race":    func() error { return flowmatic.Race(ctx, cfuncs...) },
+		"Each":    func() error { return flowmatic.Each(n, items, fail) },
+		"EachSeq": func() error { return flowmatic.EachSeq(n, slices.Values(items), fail) },
+		"RaceValue": func() error {
+			_, err := flowmatic.RaceValue(ctx, vfuncs...)
+			return err
+		},
+		"Map": func() error {
+			_, err := flowmatic.Map(ctx, n, items, func(_ context.Context, i int) (int, error) {
+				return 0, fail(i)
+			})
+			return err
+		},
+		"CollectMap": func() error {
+			_, err := flowmatic.CollectMap(n, func(i int) (int, error) {
+				return 0, fail(i)
+			}, items...)
+			return err
+		},
+	} {
+		for range 5 {
+			err := run()
+			// All and Map cancel after the first error,
+			// but every task still fails
+			if err == nil || err.Error() != want {
+				t.Fatalf("%s: %q", name, err)
+			}
+		}
+	}
+	var g flowmatic.Group
+	for i := range n {
+		g.Go(func() error { return fail(i) })
+	}
+	if err := g.Wait(); err == nil || err.Error() != want {
+		t.Fatal(err)
+	}
+}
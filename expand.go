@@ -0,0 +1,47 @@
+package flowmatic
+
+import (
+	"context"
+	"slices"
+)
+
+// ExpandTask is a task which also returns the follow-up inputs for its input,
+// such as the links found on a crawled page.
+type ExpandTask[Input, Output any] func(Input) (Output, []Input, error)
+
+// expanded is the output of an ExpandTask.
+type expanded[Input, Output any] struct {
+	out  Output
+	next []Input
+}
+
+// ManageTasksExpand is like ManageTasks,
+// but the task returns its own follow-up inputs,
+// which are queued as soon as its result is received,
+// whether or not it also returned an error.
+// The manager is optional;
+// if it is not nil,
+// it sees each result after its follow-ups are queued
+// and can queue more inputs or halt processing as usual.
+// Finding the follow-ups in the task rather than the manager
+// lets that work run in parallel on the workers
+// instead of serially in the Goroutine running the loop
+// (see BenchmarkManageTasksExpand),
+// though the loop still queues every input.
+// If a task panics during execution,
+// the panic will be caught and rethrown in the parent Goroutine.
+func ManageTasksExpand[Input, Output any](numWorkers int, task ExpandTask[Input, Output], manager Manager[Input, Output], initial ...Input) {
+	t := func(_ context.Context, in Input) (expanded[Input, Output], error) {
+		out, next, err := task(in)
+		return expanded[Input, Output]{out, next}, err
+	}
+	m := func(r Result[Input, expanded[Input, Output]]) ([]Input, Action) {
+		if manager == nil {
+			return r.Out.next, Continue
+		}
+		items, ok := manager(r.In, r.Out.out, r.Err)
+		// copy, so the slice returned by the task is never written to
+		return slices.Concat(r.Out.next, items), continueIf(ok)
+	}
+	_ = rethrow(manageFunc(context.Background(), numWorkers, t, m, newFIFO(initial...)))
+}
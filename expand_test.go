@@ -0,0 +1,120 @@
+package flowmatic_test
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+func TestManageTasksExpand(t *testing.T) {
+	errOdd := errors.New("odd")
+	task := func(n int) (int, []int, error) {
+		var next []int
+		if n < 8 {
+			next = []int{2 * n, 2*n + 1}
+		}
+		if n%2 == 1 {
+			return 0, next, errOdd
+		}
+		return n, next, nil
+	}
+	var seen []int
+	manager := func(in, out int, err error) ([]int, bool) {
+		if errors.Is(err, errOdd) != (in%2 == 1) {
+			t.Fatal(in, err)
+		}
+		seen = append(seen, in)
+		return nil, true
+	}
+	flowmatic.ManageTasksExpand(4, task, manager, 1)
+	slices.Sort(seen)
+	if s := fmt.Sprint(seen); s != "[1 2 3 4 5 6 7 8 9 10 11 12 13 14 15]" {
+		t.Fatal(s)
+	}
+	// without a manager
+	var ran atomic.Int64
+	counting := func(n int) (int, []int, error) {
+		ran.Add(1)
+		return task(n)
+	}
+	flowmatic.ManageTasksExpand(4, counting, nil, 1)
+	if n := ran.Load(); n != 15 {
+		t.Fatal(n)
+	}
+	// halting from the manager
+	count := 0
+	flowmatic.ManageTasksExpand(4, task, func(in, out int, err error) ([]int, bool) {
+		count++
+		return nil, in != 1
+	}, 1)
+	if count != 1 {
+		t.Fatal(count)
+	}
+}
+
+func TestManageTasksExpand_keepsTaskSlice(t *testing.T) {
+	// the task keeps the follow-ups it returns, with room to spare
+	kept := make([]int, 1, 4)
+	kept[0] = 1
+	task := func(n int) (int, []int, error) {
+		if n == 0 {
+			return n, kept, nil
+		}
+		return n, nil, nil
+	}
+	manager := func(in, out int, err error) ([]int, bool) {
+		if in == 0 {
+			return []int{2}, true
+		}
+		return nil, true
+	}
+	flowmatic.ManageTasksExpand(1, task, manager, 0)
+	if extra := kept[:2]; extra[1] != 0 {
+		t.Fatal(extra)
+	}
+}
+
+// BenchmarkManageTasksExpand compares finding follow-up inputs in the task
+// with finding them in the manager
+// for a crawl of tiny tasks.
+// Either way the loop queues the inputs,
+// but only the task can find them in parallel.
+func BenchmarkManageTasksExpand(b *testing.B) {
+	const size = 1 << 12
+	children := func(n int) []int {
+		// a little work, like extracting links from a page
+		deadline := time.Now().Add(2 * time.Microsecond)
+		for time.Now().Before(deadline) {
+		}
+		if n >= size/2 {
+			return nil
+		}
+		return []int{2 * n, 2*n + 1}
+	}
+	b.Run("task", func(b *testing.B) {
+		b.ReportAllocs()
+		task := func(n int) (int, []int, error) {
+			return n, children(n), nil
+		}
+		for range b.N {
+			flowmatic.ManageTasksExpand(4, task, nil, 1)
+		}
+	})
+	b.Run("manager", func(b *testing.B) {
+		b.ReportAllocs()
+		task := func(n int) (int, error) {
+			return n, nil
+		}
+		manager := func(in, out int, err error) ([]int, bool) {
+			return children(in), true
+		}
+		for range b.N {
+			flowmatic.ManageTasks(4, task, manager, 1)
+		}
+	})
+}
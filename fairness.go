@@ -0,0 +1,94 @@
+package flowmatic
+
+import (
+	"time"
+)
+
+// Fairness keeps the inputs received from the inputs channel of ManageTasksChanWith
+// separate from the inputs returned by the manager,
+// and dispatches them in a fixed ratio:
+// external inputs from the channel, then internal inputs from the manager,
+// repeating.
+// When one kind of input runs out,
+// the other kind is dispatched until it arrives again,
+// so neither kind waits for the other.
+// Without Fairness, inputs from both sources share a single queue,
+// so a manager which produces many inputs
+// can delay inputs received from the channel.
+// Fairness panics if external or internal is less than 1.
+func Fairness(external, internal int) Option {
+	if external < 1 || internal < 1 {
+		panic("flowmatic: Fairness ratio must be positive")
+	}
+	return func(o *options) {
+		o.fairExternal = external
+		o.fairInternal = internal
+	}
+}
+
+// externalPusher is implemented by a queue
+// which keeps inputs from outside the loop separate.
+type externalPusher[T any] interface {
+	pushExternal(item T)
+}
+
+// fair is a queue which dispatches the items of two queues
+// in a weighted round robin.
+type fair[T any] struct {
+	internal queue[T]
+	external *fifo[T]
+	// each cycle takes the first ext turns from external
+	// and the rest from internal.
+	ext, cycle, turn int
+	// fromExternal is whether peek chose external.
+	fromExternal bool
+}
+
+func newFair[T any](internal queue[T], external, internalN int) *fair[T] {
+	return &fair[T]{
+		internal: internal,
+		external: newFIFO[T](),
+		ext:      external,
+		cycle:    external + internalN,
+	}
+}
+
+func (q *fair[T]) push(items ...T)     { q.internal.push(items...) }
+func (q *fair[T]) pushExternal(item T) { q.external.push(item) }
+func (q *fair[T]) len() int            { return q.internal.len() + q.external.len() }
+
+func (q *fair[T]) peek() (T, bool) {
+	first, second := queue[T](q.internal), queue[T](q.external)
+	q.fromExternal = q.turn < q.ext
+	if q.fromExternal {
+		first, second = second, first
+	}
+	if item, ok := first.peek(); ok {
+		return item, true
+	}
+	q.fromExternal = !q.fromExternal
+	return second.peek()
+}
+
+func (q *fair[T]) pop() {
+	if q.fromExternal {
+		q.external.pop()
+	} else {
+		q.internal.pop()
+	}
+	q.turn = (q.turn + 1) % q.cycle
+}
+
+func (q *fair[T]) wait() <-chan time.Time {
+	if w, ok := q.internal.(waiter); ok {
+		return w.wait()
+	}
+	return nil
+}
+
+func (q *fair[T]) failed() error {
+	if f, ok := q.internal.(failer); ok {
+		return f.failed()
+	}
+	return nil
+}
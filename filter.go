@@ -0,0 +1,32 @@
+package flowmatic
+
+import (
+	"context"
+)
+
+// Filter starts numWorkers concurrent workers (or GOMAXPROCS workers if numWorkers < 1),
+// runs task once for each input,
+// and returns the outputs for which keep returns true,
+// in the order the tasks complete.
+// The keep function is run serially,
+// so it does not need to be safe for concurrent use.
+// If a task returns an error,
+// keep is called with the zero Output and the error,
+// and a zero Output is added to the results if keep returns true.
+// If a task panics during execution,
+// the panic will be caught and rethrown in the parent Goroutine.
+func Filter[Input, Output any](numWorkers int, task Task[Input, Output], keep func(Output, error) bool, initial ...Input) []Output {
+	var outputs []Output
+	manager := func(_ Input, out Output, err error) ([]Input, bool) {
+		if err != nil {
+			var zero Output
+			out = zero
+		}
+		if keep(out, err) {
+			outputs = append(outputs, out)
+		}
+		return nil, true
+	}
+	_ = rethrow(manage(context.Background(), numWorkers, ignoreContext(task), manager, newFIFO(initial...)))
+	return outputs
+}
@@ -0,0 +1,26 @@
+package flowmatic_test
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+func TestFilter(t *testing.T) {
+	errs := 0
+	even := func(n int, err error) bool {
+		if err != nil {
+			errs++
+			return false
+		}
+		return n%2 == 0
+	}
+	out := flowmatic.Filter(2, strconv.Atoi, even, "1", "2", "x", "4", "5")
+	slices.Sort(out)
+	if s := fmt.Sprint(out); s != "[2 4]" || errs != 1 {
+		t.Fatal(s, errs)
+	}
+}
@@ -0,0 +1,15 @@
+package flowmatic
+
+// ManageTasksFlat is like ManageTasks,
+// but each task produces any number of outputs,
+// such as the sections of a document,
+// and the manager receives all of the outputs of a task at once
+// along with its error.
+// The manager does not receive the input of the task;
+// to see it, use ManageTasks with a slice Output type.
+func ManageTasksFlat[Input, Output any](numWorkers int, task func(Input) ([]Output, error), manager func([]Output, error) ([]Input, bool), initial ...Input) {
+	m := func(_ Input, outs []Output, err error) ([]Input, bool) {
+		return manager(outs, err)
+	}
+	ManageTasks(numWorkers, task, m, initial...)
+}
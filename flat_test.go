@@ -0,0 +1,36 @@
+package flowmatic_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+func TestManageTasksFlat(t *testing.T) {
+	// split documents into sections, and sections into words
+	task := func(doc string) ([]string, error) {
+		if strings.Contains(doc, "\n") {
+			return strings.Split(doc, "\n"), nil
+		}
+		return strings.Fields(doc), nil
+	}
+	var words []string
+	manager := func(outs []string, err error) ([]string, bool) {
+		var sections []string
+		for _, out := range outs {
+			if strings.ContainsAny(out, " \n") {
+				sections = append(sections, out)
+			} else {
+				words = append(words, out)
+			}
+		}
+		return sections, true
+	}
+	flowmatic.ManageTasksFlat(2, task, manager, "a b\nc d e\nf", "g h")
+	slices.Sort(words)
+	if strings.Join(words, "") != "abcdefgh" {
+		t.Fatal(words)
+	}
+}
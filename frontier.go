@@ -0,0 +1,171 @@
+package flowmatic
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/carlmjohnson/deque"
+)
+
+// Frontier is a queue of inputs which may be shared between processes,
+// such as one backed by Redis or a message queue,
+// so that several processes can cooperate on a single crawl.
+// Its methods must be safe for concurrent use.
+type Frontier[T any] interface {
+	// Push adds items to the frontier.
+	Push(ctx context.Context, items ...T) error
+	// Pop removes and returns the next item,
+	// blocking until one is available or ctx is canceled,
+	// in which case it returns ctx.Err().
+	Pop(ctx context.Context) (T, error)
+}
+
+// ManageTasksFrontier is like ManageTasksWith,
+// but inputs are taken from f,
+// and the inputs returned by the manager are pushed onto f
+// instead of being queued locally.
+// An input is only taken from f once a worker is free to run it,
+// so the inputs on f are shared out between the processes
+// as their workers become free.
+// Because other processes may still push inputs,
+// a process cannot tell that the work is done
+// just because it has nothing queued or running.
+// Instead ManageTasksFrontier returns nil
+// once it has had nothing to do for idle,
+// as with the IdleTimeout option.
+// Idle should be longer than the longest task,
+// so that a process waits for the tasks running elsewhere
+// to push their follow-up inputs before giving up.
+// Inputs left on f when every process has given up
+// remain there for a later run.
+// If processing halts early,
+// the inputs taken from f whose results the manager has not seen
+// are pushed back onto f before ManageTasksFrontier returns,
+// except for tasks abandoned by the DrainTimeout option.
+// With an idle that is not positive,
+// ManageTasksFrontier runs until processing halts or ctx is canceled.
+// If pushing to f fails, processing halts and the error is returned.
+// A nil manager is treated as NoExpand.
+func ManageTasksFrontier[Input, Output any](ctx context.Context, numWorkers int, f Frontier[Input], idle time.Duration, task CTask[Input, Output], manager Manager[Input, Output], opts ...Option) error {
+	if manager == nil {
+		manager = NoExpand[Input, Output]()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	want := make(chan struct{})
+	inputs := make(chan Input)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			// wait until the loop has a free worker
+			select {
+			case <-want:
+			case <-ctx.Done():
+				return
+			}
+			item, err := f.Pop(ctx)
+			if err != nil {
+				return
+			}
+			select {
+			case inputs <- item:
+			case <-ctx.Done():
+				// give back the item nobody took
+				_ = f.Push(context.Background(), item)
+				return
+			}
+		}
+	}()
+	var pushErr error
+	m := func(in Input, out Output, err error) ([]Input, bool) {
+		items, ok := manager(in, out, err)
+		if len(items) > 0 {
+			if pushErr = f.Push(ctx, items...); pushErr != nil {
+				return nil, false
+			}
+		}
+		return nil, ok
+	}
+	var leftovers []Input
+	opts = append(opts[:len(opts):len(opts)],
+		IdleTimeout(idle),
+		withSource[Input](inputs),
+		withDemand(want),
+		withLeftovers(func(in Input) {
+			leftovers = append(leftovers, in)
+		}))
+	err := manage(ctx, numWorkers, task, m, newFIFO[Input](), opts...)
+	cancel()
+	wg.Wait()
+	if len(leftovers) > 0 {
+		if perr := f.Push(context.Background(), leftovers...); perr != nil && pushErr == nil {
+			pushErr = perr
+		}
+	}
+	err = rethrow(err)
+	if pushErr != nil {
+		return pushErr
+	}
+	if errors.Is(err, ErrIdle) {
+		return nil
+	}
+	return err
+}
+
+// NewMemoryFrontier returns an in-memory Frontier holding items,
+// which dispatches them in first-in, first-out order.
+// It is useful for tests
+// and for sharing a frontier between runs in a single process.
+func NewMemoryFrontier[T any](items ...T) Frontier[T] {
+	return &memoryFrontier[T]{
+		items: deque.Of(items...),
+		ready: make(chan struct{}, 1),
+	}
+}
+
+type memoryFrontier[T any] struct {
+	mu    sync.Mutex
+	items *deque.Deque[T]
+	// ready has a value while items may be waiting.
+	ready chan struct{}
+}
+
+func (f *memoryFrontier[T]) Push(_ context.Context, items ...T) error {
+	f.mu.Lock()
+	f.items.PushBackSlice(items)
+	f.mu.Unlock()
+	f.signal()
+	return nil
+}
+
+func (f *memoryFrontier[T]) Pop(ctx context.Context) (T, error) {
+	for {
+		f.mu.Lock()
+		item, ok := f.items.RemoveFront()
+		more := f.items.Len() > 0
+		f.mu.Unlock()
+		if ok {
+			if more {
+				f.signal()
+			}
+			return item, nil
+		}
+		select {
+		case <-f.ready:
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err()
+		}
+	}
+}
+
+func (f *memoryFrontier[T]) signal() {
+	select {
+	case f.ready <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,159 @@
+package flowmatic_test
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+func TestManageTasksFrontier(t *testing.T) {
+	f := flowmatic.NewMemoryFrontier(0)
+	task := func(_ context.Context, n int) (int, error) {
+		time.Sleep(time.Millisecond)
+		return n, nil
+	}
+	var (
+		mu   sync.Mutex
+		seen []int
+	)
+	// two processes cooperate on a binary tree of 100 nodes
+	var wg sync.WaitGroup
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			manager := func(in, out int, err error) ([]int, bool) {
+				mu.Lock()
+				seen = append(seen, in)
+				mu.Unlock()
+				var next []int
+				for _, child := range []int{in*2 + 1, in*2 + 2} {
+					if child < 100 {
+						next = append(next, child)
+					}
+				}
+				return next, true
+			}
+			err := flowmatic.ManageTasksFrontier(context.Background(), 2, f, 50*time.Millisecond, task, manager)
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	slices.Sort(seen)
+	// every node is processed exactly once
+	if len(seen) != 100 {
+		t.Fatal(seen)
+	}
+	for i, n := range seen {
+		if i != n {
+			t.Fatal(seen)
+		}
+	}
+}
+
+// countingFrontier counts the items popped from a Frontier.
+type countingFrontier struct {
+	flowmatic.Frontier[int]
+	popped atomic.Int64
+}
+
+func (f *countingFrontier) Pop(ctx context.Context) (int, error) {
+	n, err := f.Frontier.Pop(ctx)
+	if err == nil {
+		f.popped.Add(1)
+	}
+	return n, err
+}
+
+// popAll returns the items left on f.
+func popAll(f flowmatic.Frontier[int]) []int {
+	var items []int
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		n, err := f.Pop(ctx)
+		cancel()
+		if err != nil {
+			return items
+		}
+		items = append(items, n)
+	}
+}
+
+func TestManageTasksFrontier_shared(t *testing.T) {
+	f := &countingFrontier{Frontier: flowmatic.NewMemoryFrontier(0, 1, 2, 3, 4, 5, 6, 7)}
+	release := make(chan struct{})
+	popped := make(chan int64, 1)
+	task := func(_ context.Context, n int) (int, error) {
+		if n == 0 {
+			time.Sleep(20 * time.Millisecond)
+			popped <- f.popped.Load()
+			<-release
+		}
+		return n, nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- flowmatic.ManageTasksFrontier(ctx, 1, f, 0, task, nil)
+	}()
+	// with its only worker busy, the process leaves the rest on f
+	if n := <-popped; n != 1 {
+		t.Fatal("popped while no worker was free:", n)
+	}
+	cancel()
+	close(release)
+	if err := <-done; err != context.Canceled {
+		t.Fatal(err)
+	}
+}
+
+func TestManageTasksFrontier_halt(t *testing.T) {
+	f := flowmatic.NewMemoryFrontier(0, 1, 2, 3, 4, 5, 6, 7, 8, 9)
+	task := func(ctx context.Context, n int) (int, error) {
+		if n == 0 {
+			return n, nil
+		}
+		<-ctx.Done()
+		return n, ctx.Err()
+	}
+	var seen []int
+	manager := func(in, out int, err error) ([]int, bool) {
+		seen = append(seen, in)
+		return nil, false
+	}
+	err := flowmatic.ManageTasksFrontier(context.Background(), 3, f, time.Second, task, manager)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := fmt.Sprint(seen); s != "[0]" {
+		t.Fatal(s)
+	}
+	// every input the manager did not see is back on f
+	left := popAll(f)
+	slices.Sort(left)
+	if s := fmt.Sprint(left); s != "[1 2 3 4 5 6 7 8 9]" {
+		t.Fatal(s)
+	}
+}
+
+func TestManageTasksFrontier_nilManager(t *testing.T) {
+	f := flowmatic.NewMemoryFrontier(0, 1, 2, 3)
+	var calls atomic.Int64
+	task := func(_ context.Context, n int) (int, error) {
+		calls.Add(1)
+		return n, nil
+	}
+	err := flowmatic.ManageTasksFrontier(context.Background(), 2, f, 20*time.Millisecond, task, nil)
+	if err != nil || calls.Load() != 4 {
+		t.Fatal(err, calls.Load())
+	}
+}
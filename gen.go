@@ -0,0 +1,21 @@
+package flowmatic
+
+import (
+	"context"
+)
+
+// ManageTasksGen is like ManageTasks,
+// but instead of taking all of its initial inputs up front,
+// it calls gen for another input whenever its queue is empty,
+// until gen returns false.
+// Gen is only called from the Goroutine running the manager,
+// as workers become free to take another input,
+// so it is never called far ahead of the running tasks.
+// Inputs returned by the manager are dispatched
+// before any more are taken from gen.
+// There are no initial inputs,
+// so a run only ends once gen returns false
+// and the queued and running tasks are done.
+func ManageTasksGen[Input, Output any](numWorkers int, gen func() (Input, bool), task Task[Input, Output], manager Manager[Input, Output]) {
+	_ = rethrow(manage(context.Background(), numWorkers, ignoreContext(task), manager, newPull(gen)))
+}
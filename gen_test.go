@@ -0,0 +1,63 @@
+package flowmatic_test
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+func TestManageTasksGen(t *testing.T) {
+	task := func(n int) (int, error) {
+		return n, nil
+	}
+	pulled, completed := 0, 0
+	gen := func() (int, bool) {
+		if pulled-completed > 2+4 {
+			t.Errorf("gen ran ahead: pulled %d, completed %d", pulled, completed)
+		}
+		if pulled == 10 {
+			return 0, false
+		}
+		pulled++
+		return pulled * 10, true
+	}
+	var seen []int
+	manager := func(in, out int, err error) ([]int, bool) {
+		completed++
+		seen = append(seen, in)
+		if in == 10 {
+			return []int{11}, true
+		}
+		return nil, true
+	}
+	flowmatic.ManageTasksGen(2, gen, task, manager)
+	slices.Sort(seen)
+	if s := fmt.Sprint(seen); s != "[10 11 20 30 40 50 60 70 80 90 100]" {
+		t.Fatal(s)
+	}
+}
+
+func TestManageTasksGen_emptyInitial(t *testing.T) {
+	task := func(n int) (int, error) {
+		return n, nil
+	}
+	var seen []int
+	manager := func(in, out int, err error) ([]int, bool) {
+		seen = append(seen, in)
+		return nil, true
+	}
+	// a slow source is waited for
+	calls := 0
+	gen := func() (int, bool) {
+		calls++
+		time.Sleep(5 * time.Millisecond)
+		return calls, calls <= 2
+	}
+	flowmatic.ManageTasksGen(4, gen, task, manager)
+	if s := fmt.Sprint(seen); s != "[1 2]" {
+		t.Fatal(s)
+	}
+}
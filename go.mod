@@ -1,5 +1,8 @@
 module github.com/carlmjohnson/flowmatic
 
-go 1.21
+go 1.23
 
-require github.com/carlmjohnson/deque v0.23.1
+require (
+	github.com/carlmjohnson/deque v0.23.1
+	golang.org/x/time v0.5.0
+)
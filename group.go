@@ -0,0 +1,91 @@
+package flowmatic
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Group is like Do,
+// but functions can be added one at a time with Go
+// before waiting for them with Wait,
+// like errgroup.Group.
+// Unlike errgroup.Group,
+// a panicking function does not crash the program;
+// its panic is rethrown by Wait.
+// The zero value is ready to use and has no limit on active Goroutines.
+type Group struct {
+	wg  sync.WaitGroup
+	sem chan struct{}
+
+	mu       sync.Mutex
+	errs     []error
+	panicked bool
+	panicVal any
+	panicPos int
+}
+
+// SetLimit limits the number of active Goroutines in g to at most n.
+// A negative value indicates no limit.
+// SetLimit panics if it is called while any Goroutines in g are active.
+func (g *Group) SetLimit(n int) {
+	if g.sem != nil && len(g.sem) != 0 {
+		panic(fmt.Errorf("flowmatic: modify limit while %v goroutines in the group are still active", len(g.sem)))
+	}
+	if n < 0 {
+		g.sem = nil
+		return
+	}
+	g.sem = make(chan struct{}, n)
+}
+
+// Go calls fn in a new Goroutine.
+// If g has a limit, Go blocks until fn can be started without exceeding it.
+func (g *Group) Go(fn func() error) {
+	if g.sem != nil {
+		g.sem <- struct{}{}
+	}
+	g.mu.Lock()
+	pos := len(g.errs)
+	g.errs = append(g.errs, nil)
+	g.mu.Unlock()
+
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		defer func() {
+			if g.sem != nil {
+				<-g.sem
+			}
+		}()
+		defer func() {
+			if panicVal := recover(); panicVal != nil {
+				g.mu.Lock()
+				defer g.mu.Unlock()
+				if !g.panicked || pos < g.panicPos {
+					g.panicked, g.panicVal, g.panicPos = true, panicVal, pos
+				}
+			}
+		}()
+		if err := fn(); err != nil {
+			g.mu.Lock()
+			g.errs[pos] = err
+			g.mu.Unlock()
+		}
+	}()
+}
+
+// Wait waits for every function started with Go to finish.
+// Errors returned by the functions are joined into a multierror return value,
+// ordered by when the functions were started.
+// If a function panicked,
+// Wait rethrows the panic of the first of those functions started.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.panicked {
+		panic(g.panicVal)
+	}
+	return errors.Join(g.errs...)
+}
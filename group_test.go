@@ -0,0 +1,58 @@
+package flowmatic_test
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+func TestGroup(t *testing.T) {
+	var g flowmatic.Group
+	g.SetLimit(2)
+	var active, peak atomic.Int64
+	a := errors.New("a")
+	for i := range 10 {
+		g.Go(func() error {
+			n := active.Add(1)
+			defer active.Add(-1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			if i == 5 {
+				return a
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); !errors.Is(err, a) {
+		t.Fatal(err)
+	}
+	if p := peak.Load(); p > 2 {
+		t.Fatal(p)
+	}
+}
+
+func TestGroup_panic(t *testing.T) {
+	var g flowmatic.Group
+	var finished atomic.Int64
+	for i := range 10 {
+		g.Go(func() error {
+			defer finished.Add(1)
+			if i%4 == 2 {
+				panic(i)
+			}
+			return nil
+		})
+	}
+	r := try(func() { _ = g.Wait() })
+	if r != 2 || finished.Load() != 10 {
+		t.Fatal(r, finished.Load())
+	}
+}
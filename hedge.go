@@ -0,0 +1,60 @@
+package flowmatic
+
+import (
+	"context"
+	"time"
+)
+
+// Hedge wraps task so that if it has not finished within d,
+// a second copy of it is started with the same input.
+// Whichever copy finishes first provides the result,
+// and the context of the other copy is canceled.
+// The wrapped task returns as soon as the first copy finishes
+// without waiting for the other copy to exit,
+// so a copy which ignores cancelation keeps running in the background
+// until it finishes.
+// The wrapped task returns exactly one result,
+// so a hedged task counts as a single task to managers and observers.
+// If the copy which finishes first panics,
+// the panic is rethrown by the wrapped task.
+// Passing WithClock makes Hedge wait for d on that Clock.
+func Hedge[Input, Output any](d time.Duration, task CTask[Input, Output], opts ...Option) CTask[Input, Output] {
+	o := buildOptions(opts)
+	c := o.clock()
+	type result struct {
+		out   Output
+		err   error
+		panic any
+	}
+	return func(ctx context.Context, in Input) (Output, error) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		// buffered so the losing copy can still send its result and exit
+		ch := make(chan result, 2)
+		start := func() {
+			go func() {
+				defer func() {
+					if pval := recover(); pval != nil {
+						ch <- result{panic: pval}
+					}
+				}()
+				out, err := task(ctx, in)
+				ch <- result{out: out, err: err}
+			}()
+		}
+		start()
+		timer := c.NewTimer(d)
+		defer timer.Stop()
+		var r result
+		select {
+		case r = <-ch:
+		case <-timer.C():
+			start()
+			r = <-ch
+		}
+		if r.panic != nil {
+			panic(r.panic)
+		}
+		return r.out, r.err
+	}
+}
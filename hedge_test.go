@@ -0,0 +1,50 @@
+package flowmatic_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+func TestHedge(t *testing.T) {
+	var calls atomic.Int64
+	canceled := make(chan struct{}, 2)
+	task := flowmatic.Hedge(10*time.Millisecond, func(ctx context.Context, n int) (int, error) {
+		if calls.Add(1) == 1 && n == 1 {
+			// the first copy is slow
+			<-ctx.Done()
+			canceled <- struct{}{}
+			return -1, ctx.Err()
+		}
+		return n, nil
+	})
+	if out, err := task(context.Background(), 0); out != 0 || err != nil {
+		t.Fatal(out, err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if n := calls.Load(); n != 1 {
+		t.Fatal("fast task should not be hedged:", n)
+	}
+	calls.Store(0)
+	if out, err := task(context.Background(), 1); out != 1 || err != nil {
+		t.Fatal(out, err)
+	}
+	<-canceled
+	if n := calls.Load(); n != 2 {
+		t.Fatal(n)
+	}
+
+	var results int
+	manager := func(in, out int, err error) ([]int, bool) {
+		results++
+		return nil, true
+	}
+	calls.Store(0)
+	err := flowmatic.ManageTasksWith(context.Background(), 1, task, manager, []int{1})
+	if err != nil || results != 1 {
+		t.Fatal(err, results)
+	}
+}
@@ -0,0 +1,80 @@
+package flowmatic_test
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+// checkGoroutines fails t if more Goroutines are running after f returns than before.
+// Goroutines that have finished their work may take a moment to be reaped,
+// so it polls briefly before failing.
+func checkGoroutines(t *testing.T, name string, f func()) {
+	t.Helper()
+	before := runtime.NumGoroutine()
+	f()
+	var after int
+	for range 100 {
+		if after = runtime.NumGoroutine(); after <= before {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Errorf("%s: leaked Goroutines: %d > %d", name, after, before)
+}
+
+func TestManageTasks_workersExit(t *testing.T) {
+	task := func(_ context.Context, n int) (int, error) {
+		if n == 3 {
+			panic("3!!")
+		}
+		time.Sleep(time.Millisecond)
+		return n, nil
+	}
+	expand := func(in, out int, err error) ([]int, bool) {
+		return nil, true
+	}
+	stop := func(in, out int, err error) ([]int, bool) {
+		return nil, false
+	}
+	managerPanic := func(in, out int, err error) ([]int, bool) {
+		panic("manager!!")
+	}
+	run := func(manager flowmatic.Manager[int, int], initial []int, opts ...flowmatic.Option) func() {
+		return func() {
+			_ = try(func() {
+				_ = flowmatic.ManageTasksWith(context.Background(), 4, task, manager, initial, opts...)
+			})
+		}
+	}
+	checkGoroutines(t, "normal", run(expand, []int{1, 2, 4, 5, 6}))
+	checkGoroutines(t, "stop", run(stop, []int{1, 2, 4, 5, 6}))
+	checkGoroutines(t, "task panic", run(expand, []int{1, 2, 3, 4, 5, 6}))
+	checkGoroutines(t, "manager panic", run(managerPanic, []int{1, 2, 4, 5, 6}))
+	checkGoroutines(t, "collect panics", run(expand, []int{3, 3, 3, 3}, flowmatic.CollectPanics()))
+	checkGoroutines(t, "context", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := flowmatic.ManageTasksWith(ctx, 4, task, expand, []int{1, 2, 4})
+		if !errors.Is(err, context.Canceled) {
+			t.Fatal(err)
+		}
+	})
+	checkGoroutines(t, "drain timeout", func() {
+		release := make(chan struct{})
+		stuck := func(_ context.Context, n int) (int, error) {
+			if n == 1 {
+				<-release
+			}
+			return n, nil
+		}
+		_ = flowmatic.ManageTasksWith(context.Background(), 2, stuck, stop,
+			[]int{1, 2}, flowmatic.DrainTimeout(time.Millisecond))
+		// the stuck worker is leaked until its task returns
+		close(release)
+	})
+}
@@ -0,0 +1,42 @@
+package flowmatic
+
+import (
+	"context"
+)
+
+// Leveled is a task input tagged with its depth in a traversal.
+// Initial inputs have a Depth of 0,
+// and inputs returned by the manager
+// have a Depth one greater than the input whose result produced them.
+type Leveled[Input any] struct {
+	Depth int
+	Value Input
+}
+
+// ManageTasksLeveled is like ManageTasks,
+// but it tracks the depth of each input for a bounded-depth traversal.
+// The manager receives each input with its depth,
+// and the inputs it returns are queued one level deeper.
+// Inputs deeper than maxDepth are dropped when they are returned,
+// so a maxDepth of 0 only processes the initial inputs.
+// A negative maxDepth means there is no limit.
+func ManageTasksLeveled[Input, Output any](numWorkers, maxDepth int, task Task[Input, Output], manager func(in Leveled[Input], out Output, err error) ([]Input, bool), initial ...Input) {
+	wrap := func(depth int, items []Input) []Leveled[Input] {
+		if maxDepth >= 0 && depth > maxDepth {
+			return nil
+		}
+		leveled := make([]Leveled[Input], len(items))
+		for i, item := range items {
+			leveled[i] = Leveled[Input]{depth, item}
+		}
+		return leveled
+	}
+	m := func(in Leveled[Input], out Output, err error) ([]Leveled[Input], bool) {
+		items, ok := manager(in, out, err)
+		return wrap(in.Depth+1, items), ok
+	}
+	t := func(_ context.Context, in Leveled[Input]) (Output, error) {
+		return task(in.Value)
+	}
+	_ = rethrow(manage(context.Background(), numWorkers, t, m, newFIFO(wrap(0, initial)...)))
+}
@@ -0,0 +1,43 @@
+package flowmatic_test
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+func TestManageTasksLeveled(t *testing.T) {
+	task := func(n int) (int, error) {
+		return n, nil
+	}
+	for _, tc := range []struct {
+		maxDepth int
+		want     string
+	}{
+		{0, "[1:0 2:0]"},
+		{1, "[1:0 2:0 10:1 11:1 20:1 21:1]"},
+		{-1, "[1:0 2:0 10:1 11:1 20:1 21:1 100:2 101:2 110:2 111:2 200:2 201:2 210:2 211:2]"},
+	} {
+		var seen []flowmatic.Leveled[int]
+		manager := func(in flowmatic.Leveled[int], out int, err error) ([]int, bool) {
+			seen = append(seen, in)
+			if in.Value >= 100 {
+				return nil, true
+			}
+			return []int{in.Value * 10, in.Value*10 + 1}, true
+		}
+		flowmatic.ManageTasksLeveled(3, tc.maxDepth, task, manager, 1, 2)
+		slices.SortFunc(seen, func(a, b flowmatic.Leveled[int]) int {
+			return a.Value - b.Value
+		})
+		var got []string
+		for _, l := range seen {
+			got = append(got, fmt.Sprintf("%d:%d", l.Value, l.Depth))
+		}
+		if s := fmt.Sprint(got); s != tc.want {
+			t.Errorf("maxDepth %d: %s", tc.maxDepth, s)
+		}
+	}
+}
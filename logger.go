@@ -0,0 +1,47 @@
+package flowmatic
+
+import (
+	"log/slog"
+)
+
+// Logger logs the tasks of the task management loop to logger.
+// The start and end of each task are logged at slog.LevelDebug
+// with its input and duration.
+// Tasks which fail are logged at slog.LevelError with their error,
+// and tasks which panic are logged at slog.LevelError with their stack.
+// Everything is logged from the Goroutine running the loop,
+// so log lines from concurrent tasks do not interleave.
+// A task is logged as starting when it is dispatched to a worker.
+func Logger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// logStart logs the dispatch of in, if the Logger option is set.
+func (l *loop[Input, Output]) logStart(in Input) {
+	if l.opts.logger == nil {
+		return
+	}
+	l.opts.logger.Debug("flowmatic: task started", "input", inputOf(in))
+}
+
+// logDone logs the result r, if the Logger option is set.
+func (l *loop[Input, Output]) logDone(r Result[Input, Output]) {
+	logger := l.opts.logger
+	if logger == nil {
+		return
+	}
+	in := inputOf(r.In)
+	switch {
+	case r.Panic != nil:
+		logger.Error("flowmatic: task panicked",
+			"input", in, "panic", r.Panic, "duration", r.Duration, "stack", string(r.Stack))
+	case r.Err != nil:
+		logger.Error("flowmatic: task failed",
+			"input", in, "error", r.Err, "duration", r.Duration)
+	default:
+		logger.Debug("flowmatic: task done",
+			"input", in, "duration", r.Duration)
+	}
+}
@@ -0,0 +1,61 @@
+package flowmatic_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+func TestManageTasksWith_logger(t *testing.T) {
+	var buf strings.Builder
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey || a.Key == "duration" || a.Key == "stack" {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	task := func(_ context.Context, n int) (int, error) {
+		switch n {
+		case 2:
+			return 0, errors.New("two")
+		case 3:
+			panic("three")
+		}
+		return n, nil
+	}
+	manager := func(in, out int, err error) ([]int, bool) {
+		return nil, true
+	}
+	err := flowmatic.ManageTasksWith(context.Background(), 1, task, manager, []int{1, 2, 3},
+		flowmatic.Logger(logger), flowmatic.OnPanic(flowmatic.Ignore), flowmatic.Synchronous())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `level=DEBUG msg="flowmatic: task started" input=1
+level=DEBUG msg="flowmatic: task done" input=1
+level=DEBUG msg="flowmatic: task started" input=2
+level=ERROR msg="flowmatic: task failed" input=2 error=two
+level=DEBUG msg="flowmatic: task started" input=3
+level=ERROR msg="flowmatic: task panicked" input=3 panic=three
+`
+	if got := buf.String(); got != want {
+		t.Fatal(got)
+	}
+	// workers log from the loop's Goroutine too
+	buf.Reset()
+	err = flowmatic.ManageTasksWith(context.Background(), 3, task, manager, []int{1, 2, 3},
+		flowmatic.Logger(logger), flowmatic.OnPanic(flowmatic.Ignore))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(buf.String(), "\n"); n != 6 {
+		t.Fatal(buf.String())
+	}
+}
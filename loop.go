@@ -0,0 +1,818 @@
+package flowmatic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/carlmjohnson/deque"
+)
+
+// managerFunc is the most general form of manager used by loop.
+type managerFunc[Input, Output any] func(Result[Input, Output]) ([]Input, Action)
+
+// fromManager adapts a Manager into a managerFunc.
+func fromManager[Input, Output any](manager Manager[Input, Output]) managerFunc[Input, Output] {
+	if manager == nil {
+		manager = NoExpand[Input, Output]()
+	}
+	return func(r Result[Input, Output]) ([]Input, Action) {
+		items, ok := manager(r.In, r.Out, r.Err)
+		return items, continueIf(ok)
+	}
+}
+
+// fromResultManager adapts a ResultManager into a managerFunc.
+func fromResultManager[Input, Output any](manager ResultManager[Input, Output]) managerFunc[Input, Output] {
+	return func(r Result[Input, Output]) ([]Input, Action) {
+		items, ok := manager(r)
+		return items, continueIf(ok)
+	}
+}
+
+func continueIf(ok bool) Action {
+	if ok {
+		return Continue
+	}
+	return StopNow
+}
+
+// loop is the state of a task management loop.
+// It dispatches queued inputs to the workers reading from in
+// and gives the results read from out to the manager.
+type loop[Input, Output any] struct {
+	opts    *options
+	in      chan<- Input
+	out     <-chan Result[Input, Output]
+	manager managerFunc[Input, Output]
+	queue   queue[Input]
+	obs     Observer[Input]
+	// held are results which have not been given to the manager yet
+	// because the queue is full.
+	held     *deque.Deque[Result[Input, Output]]
+	inflight int
+	// started and completed count the tasks dispatched
+	// and the results given to the manager.
+	started, completed int
+	// draining is set once the manager returns DrainAndStop.
+	draining bool
+	// errStreak counts consecutive task errors for the CircuitBreaker option.
+	errStreak int
+	// cooldown fires when dispatching may resume after the circuit breaker trips.
+	cooldown <-chan time.Time
+	// panics are the panics collected under the CollectAndReturn policy.
+	panics []*PanicError
+	// workers is the number of workers,
+	// if known, and start is when run started.
+	// They are used by the RampUp option.
+	workers int
+	start   time.Time
+	// pool starts workers as they are needed.
+	// It is nil when running synchronously.
+	pool *workerPool[Input, Output]
+	// limit is the number of tasks the AutoScale option allows to run at once,
+	// or 0 if it is not set.
+	limit int
+	// lastResult is when the last result was received,
+	// for the StallTimeout option.
+	lastResult time.Time
+	// lastArrival is when the last input or result arrived,
+	// for the IdleTimeout option.
+	lastArrival time.Time
+	// parentDone is closed when the context given to the loop's caller is canceled,
+	// if the DrainUntilCanceled option is set.
+	parentDone <-chan struct{}
+	// abandoned is set if drain gave up waiting for in-flight tasks.
+	abandoned bool
+	// onQuiescent is called when there is no work left.
+	onQuiescent func() []Input
+	// onSkip is called with the inputs of skipped tasks.
+	onSkip func(Input)
+	// isBarrier reports whether an input is a barrier set with the Barrier option.
+	isBarrier func(Input) bool
+	// source supplies inputs from outside the loop.
+	// It is nil once closed.
+	source <-chan Input
+	// requested is set while the loop waits for the input
+	// it asked for with the withDemand option.
+	requested bool
+	// leftover is called with the inputs the manager never saw.
+	leftover func(Input)
+}
+
+func newLoop[Input, Output any](opts *options, in chan<- Input, out <-chan Result[Input, Output], manager managerFunc[Input, Output], q queue[Input], obs Observer[Input]) *loop[Input, Output] {
+	source, _ := opts.source.(<-chan Input)
+	leftover, _ := opts.leftover.(func(Input))
+	var onSkip func(Input)
+	if !opts.keepSkips {
+		onSkip = skipFunc[Input](opts)
+	}
+	return &loop[Input, Output]{
+		onSkip:      onSkip,
+		onQuiescent: quiescentFunc[Input](opts),
+		isBarrier:   barrierFunc[Input](opts),
+		opts:        opts,
+		in:          in,
+		out:         out,
+		manager:     manager,
+		queue:       q,
+		obs:         obs,
+		held:        deque.Make[Result[Input, Output]](0),
+		source:      source,
+		leftover:    leftover,
+	}
+}
+
+// run runs the loop until the queue is exhausted,
+// the manager halts processing,
+// or ctx is canceled.
+// Before returning, run calls cancel
+// and waits for the results of any in-flight tasks.
+func (l *loop[Input, Output]) run(ctx context.Context, cancel context.CancelFunc) (err error) {
+	l.start = l.opts.clock().Now()
+	l.lastResult = l.start
+	l.lastArrival = l.start
+	tick, stop := l.progress()
+	defer stop()
+	defer func() {
+		err = l.finish(cancel, err)
+	}()
+	if n := l.queue.len(); n > 0 {
+		l.obs.OnEnqueue(n)
+	}
+	// Results are held back from the manager while the queue is full.
+	// The loop never stops receiving from out,
+	// so workers can always deliver their results and take new inputs,
+	// and dispatching continues to shrink the queue until there is room again.
+	// Stopping receives instead could deadlock
+	// if every worker were blocked waiting to deliver a result.
+	for l.pending() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := l.queueErr(); err != nil {
+			return err
+		}
+		l.check(false)
+		l.obs.OnQueueDepth(l.queue.len(), l.inflight)
+		// results waiting in out are no longer running
+		l.recordDepth(l.inflight - len(l.out))
+		if r, ok := l.held.Head(); ok && l.handleHeld() {
+			l.held.RemoveFront()
+			if err := l.handle(r); err != nil {
+				return err
+			}
+			continue
+		}
+		inch := l.in
+		free := l.inflight < l.workers && (l.limit == 0 || l.inflight < l.limit)
+		var (
+			item Input
+			ok   bool
+		)
+		// a lazy queue only pulls an item once a worker is free to run it
+		if pulls := l.queue.len() == 0 && l.more(); !pulls || free {
+			item, ok = l.queue.peek()
+			if !ok && pulls && !l.more() {
+				continue
+			}
+		}
+		if ok && l.barrier(item) {
+			if l.inflight == 0 {
+				l.queue.pop()
+				continue
+			}
+			inch = nil
+		}
+		paused, pauseChanged := l.paused()
+		if !ok || paused || l.cooldown != nil || l.limited() {
+			inch = nil
+		}
+		rampC := l.ramp()
+		if rampC != nil || l.limit > 0 && l.inflight >= l.limit {
+			inch = nil
+		}
+		if inch != nil {
+			l.growPool()
+		}
+		source := l.source
+		if l.draining || l.opts.full(l.queue.len()) {
+			source = nil
+		}
+		want := l.want(free && l.queue.len() == 0 && l.held.Len() == 0)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-l.idle():
+			return ErrIdle
+		case <-l.stalled():
+			return ErrPossibleStall
+		case <-l.opts.stop:
+			return ErrStopped
+		case <-tick:
+			l.opts.progress(l.completed, l.inflight, l.queue.len())
+			tick = l.opts.clock().After(l.opts.progressInterval)
+		case <-l.cooldown:
+			l.cooldown = nil
+		case <-l.wait():
+		case <-rampC:
+		case <-pauseChanged:
+		case want <- struct{}{}:
+			l.requested = true
+		case inch <- item:
+			l.logStart(item)
+			l.inflight++
+			l.started++
+			l.queue.pop()
+		case item, ok := <-source:
+			if !ok {
+				l.source = nil
+				continue
+			}
+			l.requested = false
+			l.lastArrival = l.opts.clock().Now()
+			n := l.queue.len()
+			l.pushSource(item)
+			if n = l.queue.len() - n; n > 0 {
+				l.obs.OnEnqueue(n)
+			}
+		case r := <-l.out:
+			l.inflight--
+			l.lastResult = l.opts.clock().Now()
+			l.lastArrival = l.lastResult
+			l.observe(r)
+			l.scale(r.Duration)
+			if l.held.Len() > 0 || !l.handleHeld() {
+				l.held.PushBack(r)
+				continue
+			}
+			if err := l.handle(r); err != nil {
+				return err
+			}
+		}
+	}
+	return l.done()
+}
+
+// runSync is like run,
+// but instead of dispatching inputs to workers,
+// it runs task for each input inline in queue order.
+// While the queue is not empty,
+// inputs from outside the loop wait to be received,
+// so the order of manager calls depends only on the manager.
+func (l *loop[Input, Output]) runSync(ctx context.Context, cancel context.CancelFunc, task Task[Input, Output]) (err error) {
+	l.lastArrival = l.opts.clock().Now()
+	tick, stop := l.progress()
+	defer stop()
+	defer func() {
+		err = l.finish(cancel, err)
+	}()
+	if n := l.queue.len(); n > 0 {
+		l.obs.OnEnqueue(n)
+	}
+	for l.pending() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := l.queueErr(); err != nil {
+			return err
+		}
+		select {
+		case <-l.opts.stop:
+			return ErrStopped
+		case <-tick:
+			l.opts.progress(l.completed, l.inflight, l.queue.len())
+			tick = l.opts.clock().After(l.opts.progressInterval)
+		default:
+		}
+		l.check(false)
+		l.obs.OnQueueDepth(l.queue.len(), 0)
+		l.recordDepth(0)
+		if r, ok := l.held.Head(); ok && l.handleHeld() {
+			l.held.RemoveFront()
+			if err := l.handle(r); err != nil {
+				return err
+			}
+			continue
+		}
+		if l.cooldown != nil {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-l.cooldown:
+				l.cooldown = nil
+			}
+		}
+		if paused, pauseChanged := l.paused(); paused {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-pauseChanged:
+			}
+			continue
+		}
+		pulls := l.queue.len() == 0 && l.more()
+		item, ok := l.queue.peek()
+		if !ok && pulls && !l.more() {
+			continue
+		}
+		if !ok {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-l.idle():
+				return ErrIdle
+			case <-l.opts.stop:
+				return ErrStopped
+			case <-l.wait():
+			case l.want(true) <- struct{}{}:
+				l.requested = true
+			case item, ok := <-l.source:
+				if !ok {
+					l.source = nil
+					continue
+				}
+				l.requested = false
+				l.lastArrival = l.opts.clock().Now()
+				l.pushSource(item)
+				l.obs.OnEnqueue(1)
+			}
+			continue
+		}
+		l.queue.pop()
+		if l.barrier(item) {
+			continue
+		}
+		l.started++
+		l.logStart(item)
+		l.recordDepth(1)
+		var r Result[Input, Output]
+		if l.opts.noRecover {
+			r = runTaskUncaught(0, task, item, l.opts)
+		} else {
+			r = runTask(0, task, item, l.opts)
+		}
+		l.lastArrival = l.opts.clock().Now()
+		l.observe(r)
+		if l.held.Len() > 0 || !l.handleHeld() {
+			l.held.PushBack(r)
+			continue
+		}
+		if err := l.handle(r); err != nil {
+			return err
+		}
+	}
+	return l.done()
+}
+
+// check panics if the Debug option is set
+// and the bookkeeping of the loop is inconsistent.
+// If done is set, the loop is about to finish
+// because it has run out of work.
+func (l *loop[Input, Output]) check(done bool) {
+	if !l.opts.debug {
+		return
+	}
+	var problem string
+	switch {
+	case l.inflight < 0:
+		problem = "negative in-flight count"
+	case l.in == nil && l.out == nil && l.inflight != 0:
+		problem = "in-flight tasks while synchronous"
+	case l.workers > 0 && l.inflight > l.workers+cap(l.out):
+		problem = "more in-flight tasks than workers and buffered results"
+	case l.completed > l.started:
+		problem = "more results than tasks started"
+	case done && (l.inflight != 0 || l.queue.len() != 0 || l.held.Len() != 0):
+		problem = "finished with work left"
+	case done && l.source != nil && !l.draining:
+		problem = "finished with inputs still open"
+	default:
+		return
+	}
+	panic(fmt.Sprintf("flowmatic: %s: inflight=%d workers=%d queued=%d held=%d started=%d completed=%d draining=%t",
+		problem, l.inflight, l.workers, l.queue.len(), l.held.Len(), l.started, l.completed, l.draining))
+}
+
+// observe reports a task result received by the loop
+// to the observer, logger, and run statistics.
+func (l *loop[Input, Output]) observe(r Result[Input, Output]) {
+	err := r.Err
+	if r.Panic != nil {
+		err = panicError(r)
+	}
+	l.obs.OnDone(r.In, err, r.Duration)
+	l.logDone(r)
+	l.recordResult(r)
+}
+
+// progress returns a channel which fires when the Progress option, if it is set,
+// should first report.
+// The loop waits for another interval after each report.
+// The returned stop function makes a final report.
+func (l *loop[Input, Output]) progress() (tick <-chan time.Time, stop func()) {
+	if l.opts.progress == nil {
+		return nil, func() {}
+	}
+	return l.opts.clock().After(l.opts.progressInterval), func() {
+		l.opts.progress(l.completed, l.inflight, l.queue.len())
+	}
+}
+
+// finish cancels the tasks and drains the loop,
+// returning err combined with any panics collected by the drain.
+func (l *loop[Input, Output]) finish(cancel context.CancelFunc, err error) error {
+	cancel()
+	panics := l.drain()
+	l.leave()
+	if rs := l.opts.runStats; rs != nil {
+		rs.Completed = l.completed
+	}
+	if pe, ok := err.(*PanicError); ok {
+		if l.opts.collectPanics && len(panics) > 0 {
+			return append(MultiPanic{pe}, panics...)
+		}
+		return err
+	}
+	if l.opts.panicPolicy == CollectAndReturn {
+		panics = append(l.panics, panics...)
+		if len(panics) > 0 {
+			errs := make([]error, 0, len(panics)+1)
+			for _, pe := range panics {
+				errs = append(errs, pe)
+			}
+			if err != nil && !errors.Is(err, ErrStopped) {
+				errs = append(errs, err)
+			}
+			return errors.Join(errs...)
+		}
+	}
+	return err
+}
+
+// handle gives a result to the manager and queues the inputs it returns.
+func (l *loop[Input, Output]) handle(r Result[Input, Output]) error {
+	if r.Panic != nil && !l.opts.deliverPanics {
+		pe := panicError(r)
+		switch l.opts.panicPolicy {
+		case Ignore:
+			r.Err = pe
+		case CollectAndReturn:
+			l.panics = append(l.panics, pe)
+			if !l.opts.keepCollected {
+				return nil
+			}
+		default:
+			return pe
+		}
+	}
+	if r.Panic == nil && !l.opts.keepSkips && errors.Is(r.Err, ErrSkip) {
+		if l.onSkip != nil {
+			l.onSkip(r.In)
+		}
+		return nil
+	}
+	if l.opts.failFast && r.Err != nil {
+		return r.Err
+	}
+	items, action, err := l.callManager(r)
+	if err != nil {
+		return err
+	}
+	// a collected panic given to the manager only for its bookkeeping
+	// counts as neither a result nor a task error
+	if r.Panic == nil || l.opts.deliverPanics || l.opts.panicPolicy != CollectAndReturn {
+		l.completed++
+		if err := l.trip(r.Err); err != nil && action != StopNow {
+			return err
+		}
+	}
+	switch action {
+	case StopNow:
+		if r.Err != nil {
+			return fmt.Errorf("%w: %w", ErrStopped, r.Err)
+		}
+		return ErrStopped
+	case DrainAndStop:
+		l.draining = true
+	}
+	if l.draining {
+		return nil
+	}
+	n := l.queue.len()
+	l.queue.push(items...)
+	if n = l.queue.len() - n; n > 0 {
+		l.obs.OnEnqueue(n)
+	}
+	return nil
+}
+
+// pending reports whether the loop has work left to wait for.
+// Once the MaxTasks option allows no more tasks to start,
+// only the results of tasks already started are left,
+// even if skipped tasks mean fewer than MaxTasks results reach the manager.
+func (l *loop[Input, Output]) pending() bool {
+	if l.inflight > 0 || l.held.Len() > 0 {
+		return true
+	}
+	if l.limited() {
+		return false
+	}
+	return l.queue.len() > 0 || l.more() || l.source != nil && !l.draining || l.quiesce()
+}
+
+// done is called once the loop has no work left to wait for.
+// It returns ErrStopped if the MaxTasks option left inputs unprocessed.
+func (l *loop[Input, Output]) done() error {
+	if err := l.queueErr(); err != nil {
+		return err
+	}
+	if l.limited() && (l.queue.len() > 0 || l.more() || l.source != nil && !l.draining) {
+		return ErrStopped
+	}
+	l.check(true)
+	return nil
+}
+
+// limited reports whether the MaxTasks option allows no more tasks to start.
+func (l *loop[Input, Output]) limited() bool {
+	return l.opts.maxTasks > 0 && l.started >= l.opts.maxTasks
+}
+
+// handleHeld reports whether results may be given to the manager
+// rather than held back for the MaxQueue option.
+// Once no more tasks will start,
+// or while the queue is waiting for results before it dispatches more,
+// the queue can no longer shrink to make room,
+// so held results are handled even if it is full.
+func (l *loop[Input, Output]) handleHeld() bool {
+	if !l.opts.full(l.queue.len()) || l.limited() {
+		return true
+	}
+	b, ok := l.queue.(blocker)
+	return ok && b.blocked()
+}
+
+// barrier reports whether item is a barrier set with the Barrier option.
+func (l *loop[Input, Output]) barrier(item Input) bool {
+	return l.isBarrier != nil && l.isBarrier(item)
+}
+
+// idle returns a channel which fires after the IdleTimeout
+// if the loop has nothing to do but wait for inputs from outside.
+// The timeout counts from when the last input or result arrived,
+// so other wake-ups, such as progress ticks, do not restart it.
+func (l *loop[Input, Output]) idle() <-chan time.Time {
+	if l.opts.idleTimeout <= 0 || l.inflight > 0 || l.queue.len() > 0 || l.more() || l.held.Len() > 0 {
+		return nil
+	}
+	clock := l.opts.clock()
+	return clock.After(l.lastArrival.Add(l.opts.idleTimeout).Sub(clock.Now()))
+}
+
+// stalled returns a channel which fires
+// if every worker is busy, inputs are waiting,
+// and no result has arrived for the StallTimeout duration.
+func (l *loop[Input, Output]) stalled() <-chan time.Time {
+	d := l.opts.stallTimeout
+	if d <= 0 || l.workers < 1 || l.inflight < l.workers || l.queue.len() == 0 {
+		return nil
+	}
+	clock := l.opts.clock()
+	return clock.After(l.lastResult.Add(d).Sub(clock.Now()))
+}
+
+// growPool starts another worker
+// if every worker started so far is busy
+// and the pool has room for more.
+func (l *loop[Input, Output]) growPool() {
+	// results waiting in out are from workers which are free again
+	if l.pool != nil && l.inflight-len(l.out) >= l.pool.started {
+		l.pool.grow()
+	}
+}
+
+// pushSource queues an input received from outside the loop.
+func (l *loop[Input, Output]) pushSource(item Input) {
+	if q, ok := l.queue.(externalPusher[Input]); ok {
+		q.pushExternal(item)
+		return
+	}
+	l.queue.push(item)
+}
+
+// want returns the channel set with the withDemand option
+// if free is set and the loop has not already asked for an input,
+// or nil otherwise.
+func (l *loop[Input, Output]) want(free bool) chan<- struct{} {
+	if l.opts.demand == nil || l.requested || l.source == nil || l.draining || !free {
+		return nil
+	}
+	return l.opts.demand
+}
+
+// more reports whether the queue is lazy and may still have items to pull.
+func (l *loop[Input, Output]) more() bool {
+	q, ok := l.queue.(lazy)
+	return ok && q.more()
+}
+
+// quiesce is called once there is no work left.
+// It calls the OnQuiescent function, if any,
+// and reports whether it queued more inputs.
+func (l *loop[Input, Output]) quiesce() bool {
+	if l.onQuiescent == nil || l.draining {
+		return false
+	}
+	n := l.queue.len()
+	l.queue.push(l.onQuiescent()...)
+	if n = l.queue.len() - n; n > 0 {
+		l.obs.OnEnqueue(n)
+	}
+	return l.queue.len() > 0
+}
+
+// queueErr returns the error of a queue which has failed.
+func (l *loop[Input, Output]) queueErr() error {
+	if f, ok := l.queue.(failer); ok {
+		return f.failed()
+	}
+	return nil
+}
+
+// wait returns a channel which fires
+// when a held back item in the queue is ready to dispatch.
+func (l *loop[Input, Output]) wait() <-chan time.Time {
+	if w, ok := l.queue.(waiter); ok {
+		return w.wait()
+	}
+	return nil
+}
+
+// ramp returns a channel which fires
+// when the RampUp option next allows another task to run at once,
+// or nil if the RampUp option is not holding back dispatch.
+// Worker i becomes available i*d/workers after the loop starts.
+func (l *loop[Input, Output]) ramp() <-chan time.Time {
+	d := l.opts.rampUp
+	if d <= 0 || l.workers <= 1 {
+		return nil
+	}
+	clock := l.opts.clock()
+	elapsed := clock.Now().Sub(l.start)
+	if elapsed >= d {
+		return nil
+	}
+	// a ramp up shorter than one nanosecond per worker
+	// still releases workers one at a time
+	step := max(d/time.Duration(l.workers), 1)
+	available := int(elapsed/step) + 1
+	if l.inflight < available {
+		return nil
+	}
+	return clock.After(time.Duration(available)*step - elapsed)
+}
+
+// scale adjusts the concurrency limit of the AutoScale option
+// after a task which ran for dur.
+// A task slower than the target latency removes a worker,
+// and a fast task adds one
+// if every allowed worker is busy and inputs are waiting.
+func (l *loop[Input, Output]) scale(dur time.Duration) {
+	if l.limit == 0 {
+		return
+	}
+	switch {
+	case dur > l.opts.scaleTarget:
+		l.limit = max(l.limit-1, l.opts.scaleMin)
+	case l.inflight+1 >= l.limit && l.queue.len() > 0:
+		l.limit = min(l.limit+1, l.opts.scaleMax)
+	}
+}
+
+// paused reports whether the Pausable option has paused the loop
+// and returns a channel which is closed when that changes.
+// Without a Pauser, the channel is nil.
+func (l *loop[Input, Output]) paused() (bool, <-chan struct{}) {
+	if l.opts.pauser == nil {
+		return false, nil
+	}
+	return l.opts.pauser.state()
+}
+
+// trip counts consecutive task errors for the CircuitBreaker option.
+// If the breaker trips without a cooldown, trip returns ErrCircuitOpen.
+// Otherwise, dispatching pauses until the cooldown has passed.
+func (l *loop[Input, Output]) trip(err error) error {
+	if l.opts.breakerErrs < 1 {
+		return nil
+	}
+	if err == nil {
+		l.errStreak = 0
+		return nil
+	}
+	l.errStreak++
+	if l.errStreak < l.opts.breakerErrs {
+		return nil
+	}
+	l.errStreak = 0
+	if l.opts.breakerCooldown <= 0 {
+		return fmt.Errorf("%w: %w", ErrCircuitOpen, err)
+	}
+	l.cooldown = l.opts.clock().After(l.opts.breakerCooldown)
+	return nil
+}
+
+// callManager calls the manager,
+// recovering a panic into a *PanicError
+// so that it is rethrown only after the loop has drained.
+func (l *loop[Input, Output]) callManager(r Result[Input, Output]) (items []Input, action Action, err error) {
+	defer func() {
+		if pval := recover(); pval != nil {
+			err = &PanicError{Input: inputOf(r.In), Value: pval, Stack: l.opts.captureStack(), manager: true}
+		}
+	}()
+	if l.opts.stats != nil {
+		*l.opts.stats = Stats{
+			QueueLen:  l.queue.len(),
+			Inflight:  l.inflight,
+			Completed: l.completed,
+		}
+	}
+	items, action = l.manager(r)
+	return items, action, nil
+}
+
+// panicError returns a *PanicError for the result of a panicking task.
+func panicError[Input, Output any](r Result[Input, Output]) *PanicError {
+	return &PanicError{Input: inputOf(r.In), Value: r.Panic, Stack: r.Stack}
+}
+
+// inputOf returns the input to report in a *PanicError,
+// unwrapping inputs wrapped by the package for internal bookkeeping.
+func inputOf(in any) any {
+	if w, ok := in.(interface{ unwrapInput() any }); ok {
+		return w.unwrapInput()
+	}
+	return in
+}
+
+// drain discards the results of any in-flight tasks
+// once they have been reported like any other result.
+// If CollectPanics is set, drain returns the panics of the discarded results.
+// If DrainTimeout is set and passes first,
+// the abandon channel is closed,
+// or DrainUntilCanceled is set and the caller's context is canceled,
+// drain gives up on the remaining tasks and sets abandoned.
+func (l *loop[Input, Output]) drain() (panics []*PanicError) {
+	collect := func(r Result[Input, Output]) {
+		if l.leftover != nil {
+			l.leftover(r.In)
+		}
+		if r.Panic != nil && (l.opts.collectPanics || l.opts.panicPolicy == CollectAndReturn) {
+			panics = append(panics, panicError(r))
+		}
+	}
+	for l.held.Len() > 0 {
+		r, _ := l.held.RemoveFront()
+		collect(r)
+	}
+	var timeout <-chan time.Time
+	if l.opts.drainTimeout > 0 && l.inflight > 0 {
+		t := l.opts.clock().NewTimer(l.opts.drainTimeout)
+		defer t.Stop()
+		timeout = t.C()
+	}
+	for ; l.inflight > 0; l.inflight-- {
+		select {
+		case r := <-l.out:
+			// the manager never sees these results,
+			// but they are still accounted for
+			l.observe(r)
+			collect(r)
+		case <-timeout:
+			l.abandoned = true
+			return panics
+		case <-l.opts.abandon:
+			l.abandoned = true
+			return panics
+		case <-l.parentDone:
+			l.abandoned = true
+			return panics
+		}
+	}
+	return panics
+}
+
+// leave gives the inputs still in the queue to the withLeftovers function, if any.
+func (l *loop[Input, Output]) leave() {
+	for l.leftover != nil && l.queue.len() > 0 {
+		item, ok := l.queue.peek()
+		if !ok {
+			return
+		}
+		l.queue.pop()
+		l.leftover(item)
+	}
+}
@@ -0,0 +1,20 @@
+package flowmatic
+
+import (
+	"context"
+)
+
+// ManageActions is like ManageTasks,
+// but for tasks which are only run for their side effects
+// and return no output besides an error.
+// The manager receives the input and error of each action.
+func ManageActions[Input any](numWorkers int, action func(Input) error, manager func(Input, error) ([]Input, bool), initial ...Input) {
+	type void struct{}
+	task := func(_ context.Context, in Input) (void, error) {
+		return void{}, action(in)
+	}
+	m := func(in Input, _ void, err error) ([]Input, bool) {
+		return manager(in, err)
+	}
+	_ = rethrow(manage(context.Background(), numWorkers, task, m, newFIFO(initial...)))
+}
@@ -0,0 +1,46 @@
+package flowmatic_test
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+func TestManageActions(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		visited []string
+	)
+	action := func(path string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		visited = append(visited, path)
+		if path == "/b" {
+			return errors.New("bad")
+		}
+		return nil
+	}
+	var failed []string
+	manager := func(path string, err error) ([]string, bool) {
+		if err != nil {
+			failed = append(failed, path)
+			return nil, true
+		}
+		if len(path) < 3 {
+			return []string{path + "/x"}, true
+		}
+		return nil, true
+	}
+	flowmatic.ManageActions(2, action, manager, "/a", "/b")
+	slices.Sort(visited)
+	if s := fmt.Sprint(visited); s != "[/a /a/x /b]" {
+		t.Fatal(s)
+	}
+	if s := fmt.Sprint(failed); s != "[/b]" {
+		t.Fatal(s)
+	}
+}
@@ -0,0 +1,27 @@
+package flowmatic
+
+import (
+	"context"
+)
+
+// ManageTasksChan is like ManageTasks,
+// but inputs received from the inputs channel are also queued.
+// ManageTasksChan keeps running until inputs is closed
+// and every queued and in-flight task has been given to the manager,
+// or until the manager halts processing.
+// Once the manager halts processing,
+// nothing more is received from inputs,
+// so senders should stop sending.
+// Starting with nothing queued is normal,
+// so ManageTasksChan blocks waiting for inputs
+// even while no tasks are queued or running.
+func ManageTasksChan[Input, Output any](numWorkers int, task Task[Input, Output], manager Manager[Input, Output], inputs <-chan Input) {
+	_ = rethrow(manage(context.Background(), numWorkers, ignoreContext(task), manager, newFIFO[Input](), withSource(inputs)))
+}
+
+// ManageTasksChanWith is like ManageTasksChan,
+// but it takes a context-aware task and is configured with options.
+// It returns like ManageTasksWith.
+func ManageTasksChanWith[Input, Output any](ctx context.Context, numWorkers int, task CTask[Input, Output], manager Manager[Input, Output], inputs <-chan Input, opts ...Option) error {
+	return rethrow(manage(ctx, numWorkers, task, manager, newFIFO[Input](), append(opts[:len(opts):len(opts)], withSource(inputs))...))
+}
@@ -0,0 +1,172 @@
+package flowmatic_test
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+func TestManageTasksChan(t *testing.T) {
+	inputs := make(chan int)
+	go func() {
+		defer close(inputs)
+		for i := range 5 {
+			inputs <- i * 10
+		}
+	}()
+	task := func(n int) (int, error) {
+		return n, nil
+	}
+	var seen []int
+	manager := func(in, out int, err error) ([]int, bool) {
+		seen = append(seen, out)
+		if in%10 == 0 {
+			return []int{in + 1}, true
+		}
+		return nil, true
+	}
+	flowmatic.ManageTasksChan(3, task, manager, inputs)
+	slices.Sort(seen)
+	if s := fmt.Sprint(seen); s != "[0 1 10 11 20 21 30 31 40 41]" {
+		t.Fatal(s)
+	}
+}
+
+func TestManageTasksChan_stop(t *testing.T) {
+	inputs := make(chan int, 1)
+	inputs <- 1
+	task := func(n int) (int, error) {
+		return n, nil
+	}
+	n := 0
+	manager := func(in, out int, err error) ([]int, bool) {
+		n++
+		return nil, false
+	}
+	// returns without inputs being closed
+	flowmatic.ManageTasksChan(1, task, manager, inputs)
+	if n != 1 {
+		t.Fatal(n)
+	}
+}
+
+func TestManageTasksChanWith_idleTimeout(t *testing.T) {
+	inputs := make(chan int)
+	go func() {
+		// each gap is shorter than the timeout
+		for i := range 5 {
+			time.Sleep(5 * time.Millisecond)
+			inputs <- i
+		}
+	}()
+	task := func(_ context.Context, n int) (int, error) {
+		return n, nil
+	}
+	calls := 0
+	manager := func(in, out int, err error) ([]int, bool) {
+		calls++
+		return nil, true
+	}
+	err := flowmatic.ManageTasksChanWith(context.Background(), 2, task, manager, inputs,
+		flowmatic.IdleTimeout(50*time.Millisecond))
+	if err != flowmatic.ErrIdle || calls != 5 {
+		t.Fatal(err, calls)
+	}
+}
+
+func TestManageTasksChanWith_idleProgress(t *testing.T) {
+	// progress ticks come faster than the idle timeout
+	inputs := make(chan int)
+	task := func(_ context.Context, n int) (int, error) {
+		return n, nil
+	}
+	manager := func(in, out int, err error) ([]int, bool) {
+		return nil, true
+	}
+	ticks := 0
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	err := flowmatic.ManageTasksChanWith(ctx, 2, task, manager, inputs,
+		flowmatic.IdleTimeout(200*time.Millisecond),
+		flowmatic.Progress(50*time.Millisecond, func(done, inflight, queued int) {
+			ticks++
+		}))
+	if err != flowmatic.ErrIdle || ticks < 1 {
+		t.Fatal(err, ticks)
+	}
+}
+
+func TestManageTasksChanWith_fairness(t *testing.T) {
+	task := func(_ context.Context, n int) (int, error) {
+		return n, nil
+	}
+	inputs := make(chan int, 5)
+	inputs <- 0
+	var seen []int
+	internal := 0
+	manager := func(in, out int, err error) ([]int, bool) {
+		seen = append(seen, in)
+		if len(seen) == 10 {
+			for i := range 5 {
+				inputs <- 1000 + i
+			}
+			close(inputs)
+		}
+		if in >= 1000 || internal >= 100 {
+			return nil, true
+		}
+		next := make([]int, 5)
+		for i := range next {
+			internal++
+			next[i] = internal
+		}
+		return next, true
+	}
+	err := flowmatic.ManageTasksChanWith(context.Background(), 1, task, manager, inputs,
+		flowmatic.Fairness(1, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := slices.IndexFunc(seen, func(n int) bool { return n == 1004 })
+	if last < 0 || len(seen) != 106 {
+		t.Fatal(len(seen), seen)
+	}
+	// about 40 internal inputs are queued when the external inputs arrive,
+	// but they only wait for three internal inputs each
+	if last > 10+5*4+2 {
+		t.Fatal("external inputs were starved:", last, seen)
+	}
+}
+
+func TestManageTasksChan_emptyInitial(t *testing.T) {
+	task := func(n int) (int, error) {
+		return n, nil
+	}
+	var seen []int
+	manager := func(in, out int, err error) ([]int, bool) {
+		seen = append(seen, in)
+		return nil, true
+	}
+	inputs := make(chan int)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		flowmatic.ManageTasksChan(4, task, manager, inputs)
+	}()
+	// nothing is queued or running, but the run waits for inputs
+	select {
+	case <-done:
+		t.Fatal("returned before inputs was closed")
+	case <-time.After(20 * time.Millisecond):
+	}
+	inputs <- 1
+	close(inputs)
+	<-done
+	if s := fmt.Sprint(seen); s != "[1]" {
+		t.Fatal(s)
+	}
+}
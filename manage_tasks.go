@@ -1,52 +1,292 @@
 package flowmatic
 
 import (
-	"github.com/carlmjohnson/deque"
+	"context"
+	"errors"
+	"runtime"
+	"slices"
+	"time"
 )
 
 // Manager is a function that serially examines Task results to see if it produced any new Inputs.
 // Returning false will halt the processing of future tasks.
+// ManageTasks, ManageTasksContext, ManageCTasks, and ManageTasksWith
+// treat a nil Manager as NoExpand.
 type Manager[Input, Output any] func(Input, Output, error) (tasks []Input, ok bool)
 
+// NoExpand returns a Manager which never adds inputs or halts processing,
+// for running a fixed set of inputs through ManageTasks purely for the side effects of the tasks.
+func NoExpand[Input, Output any]() Manager[Input, Output] {
+	return func(Input, Output, error) ([]Input, bool) {
+		return nil, true
+	}
+}
+
+// ResultManager is like Manager,
+// but it examines the full Result of each task.
+type ResultManager[Input, Output any] func(Result[Input, Output]) (tasks []Input, ok bool)
+
 // Task is a function that can concurrently transform an input into an output.
 type Task[Input, Output any] func(in Input) (out Output, err error)
 
+// CTask is a context-aware Task.
+type CTask[Input, Output any] func(ctx context.Context, in Input) (out Output, err error)
+
 // ManageTasks manages tasks using numWorkers concurrent workers (or GOMAXPROCS workers if numWorkers < 1)
 // which produce output consumed by a serially run manager.
 // The manager should return a slice of new task inputs based on prior task results,
 // or return false to halt processing.
+// Inputs are dispatched in first-in, first-out order,
+// and the manager sees results in the order the tasks complete.
+// With a single worker, that is the order the inputs were queued.
+// To see results in queue order with multiple workers, use ManageTasksOrdered.
 // If a task panics during execution,
-// the panic will be caught and rethrown in the parent Goroutine.
+// the panic will be caught and rethrown in the parent Goroutine
+// as a *PanicError recording the input of the task.
+// The same is true of the other functions built on ManageTasks.
+// If the manager panics,
+// the panic is rethrown after any running tasks have finished.
+// However it halts,
+// ManageTasks does not return until all of its worker Goroutines have exited.
+// With no initial inputs, there is nothing to do,
+// so ManageTasks returns immediately without calling task or manager.
+// To wait for inputs from outside the run instead,
+// use ManageTasksChan, ManageTasksGen, or the OnQuiescent option.
 func ManageTasks[Input, Output any](numWorkers int, task Task[Input, Output], manager Manager[Input, Output], initial ...Input) {
-	in, out := TaskPool(numWorkers, task)
+	_ = ManageTasksContext(context.Background(), numWorkers, task, manager, initial...)
+}
+
+// ManageTasksContext is like ManageTasks,
+// but it stops submitting new tasks once ctx is canceled.
+// Tasks which are already running are waited for before ManageTasksContext returns ctx.Err().
+// If the queue is exhausted or the manager halts processing,
+// ManageTasksContext returns nil.
+func ManageTasksContext[Input, Output any](ctx context.Context, numWorkers int, task Task[Input, Output], manager Manager[Input, Output], initial ...Input) error {
+	return rethrow(manage(ctx, numWorkers, ignoreContext(task), manager, newFIFO(initial...)))
+}
+
+// ManageTasksDeadline is like ManageTasksContext,
+// but instead of a context it takes a deadline.
+// Once the deadline passes,
+// no new tasks are submitted,
+// tasks which are already running are waited for,
+// and ManageTasksDeadline returns context.DeadlineExceeded.
+func ManageTasksDeadline[Input, Output any](deadline time.Time, numWorkers int, task Task[Input, Output], manager Manager[Input, Output], initial ...Input) error {
+	// The context uses a single timer for the whole run.
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	return ManageTasksContext(ctx, numWorkers, task, manager, initial...)
+}
+
+// ManageCTasks is like ManageTasksContext,
+// but each task receives a child context of ctx
+// which is canceled once the manager halts processing,
+// ctx is canceled,
+// or the queue is exhausted.
+// Tasks which are still running are waited for before ManageCTasks returns.
+func ManageCTasks[Input, Output any](ctx context.Context, numWorkers int, task CTask[Input, Output], manager Manager[Input, Output], initial ...Input) error {
+	return rethrow(manage(ctx, numWorkers, task, manager, newFIFO(initial...)))
+}
+
+// ManageTasksWith is like ManageCTasks,
+// but it is configured with options.
+// Unless the DrainTimeout or DrainUntilCanceled option gives up on a stuck task,
+// ManageTasksWith does not return until all of its worker Goroutines have exited.
+func ManageTasksWith[Input, Output any](ctx context.Context, numWorkers int, task CTask[Input, Output], manager Manager[Input, Output], initial []Input, opts ...Option) error {
+	return rethrow(manage(ctx, numWorkers, task, manager, newFIFO(initial...), opts...))
+}
+
+// ManageTasksPriority is like ManageTasks,
+// but queued inputs are dispatched in priority order
+// rather than first-in, first-out.
+// An input a is dispatched before an input b if less(a, b) is true.
+// The queue is a binary heap,
+// so adding and dispatching inputs are O(log n).
+func ManageTasksPriority[Input, Output any](numWorkers int, less func(a, b Input) bool, task Task[Input, Output], manager Manager[Input, Output], initial ...Input) {
+	_ = rethrow(manage(context.Background(), numWorkers, ignoreContext(task), manager, newPriority(less, initial...)))
+}
+
+// ManageTasksShuffle is like ManageTasks,
+// but queued inputs are dispatched in random order
+// rather than first-in, first-out,
+// which avoids hitting downstream resources in a predictable pattern.
+// The choice of input to dispatch next is determined by seed
+// and the inputs queued at the time,
+// so a run over a fixed set of inputs can be reproduced exactly.
+func ManageTasksShuffle[Input, Output any](seed int64, numWorkers int, task Task[Input, Output], manager Manager[Input, Output], initial ...Input) {
+	_ = rethrow(manage(context.Background(), numWorkers, ignoreContext(task), manager, newShuffle(seed, slices.Clone(initial)...)))
+}
+
+// ManageTasksSet is like ManageTasks,
+// but each distinct input is only queued once.
+// Any input returned by the manager
+// which has already been queued is silently dropped.
+// Because every input ever queued is remembered until ManageTasksSet returns,
+// memory use grows with the number of distinct inputs,
+// which may be a problem for unbounded input spaces.
+func ManageTasksSet[Input comparable, Output any](numWorkers int, task Task[Input, Output], manager Manager[Input, Output], initial ...Input) {
+	identity := func(in Input) Input { return in }
+	_ = rethrow(manage(context.Background(), numWorkers, ignoreContext(task), manager, newDedup(newFIFO[Input](), identity, initial...)))
+}
+
+// ManageTasksDedupBy is like ManageTasksSet,
+// but inputs are considered duplicates if keyOf returns the same key for them,
+// so inputs need not be comparable.
+// A key function may also canonicalize inputs,
+// such as by normalizing URLs,
+// so that inputs which look different are treated as duplicates.
+// Only the first input queued with each key is processed.
+func ManageTasksDedupBy[Input any, Key comparable, Output any](numWorkers int, keyOf func(Input) Key, task Task[Input, Output], manager Manager[Input, Output], initial ...Input) {
+	_ = rethrow(manage(context.Background(), numWorkers, ignoreContext(task), manager, newDedup(newFIFO[Input](), keyOf, initial...)))
+}
+
+// ManageTasksTimed is like ManageTasks,
+// but the manager receives the full Result of each task,
+// including how long it ran and which worker ran it.
+func ManageTasksTimed[Input, Output any](numWorkers int, task Task[Input, Output], manager ResultManager[Input, Output], initial ...Input) {
+	_ = rethrow(manageFunc(context.Background(), numWorkers, ignoreContext(task), fromResultManager(manager), newFIFO(initial...)))
+}
+
+// ManageTasksRecover is like ManageTasksTimed,
+// but a panicking task does not halt processing.
+// Instead, the manager receives its Result
+// with the recovered value in Panic and the stack trace in Stack,
+// and it may choose to continue or halt.
+// The worker which ran the panicking task
+// is already free to run other tasks by the time the manager sees the Result.
+// A panic by the manager itself is still rethrown.
+func ManageTasksRecover[Input, Output any](numWorkers int, task Task[Input, Output], manager ResultManager[Input, Output], initial ...Input) {
+	_ = rethrow(manageFunc(context.Background(), numWorkers, ignoreContext(task), fromResultManager(manager), newFIFO(initial...), deliverPanics()))
+}
+
+// ManageTasksErr is like ManageTasks,
+// but it returns an error instead of panicking.
+// If a task or the manager panics, ManageTasksErr returns a *PanicError.
+// If the manager halts processing,
+// ManageTasksErr returns an error wrapping ErrStopped
+// and the error of the task result that caused the halt, if any.
+func ManageTasksErr[Input, Output any](numWorkers int, task Task[Input, Output], manager Manager[Input, Output], initial ...Input) error {
+	return manage(context.Background(), numWorkers, ignoreContext(task), manager, newFIFO(initial...))
+}
+
+// rethrow converts the error returned by manage
+// into the error returned by ManageTasksContext.
+// Task panics are rethrown as the *PanicError itself
+// so the input of the task is not lost,
+// but manager panics are rethrown with their original value.
+func rethrow(err error) error {
+	switch err := err.(type) {
+	case *PanicError:
+		if err.manager {
+			panic(err.Value)
+		}
+		panic(err)
+	case MultiPanic:
+		panic(err)
+	}
+	if errors.Is(err, ErrStopped) {
+		return nil
+	}
+	return err
+}
+
+// ignoreContext adapts a Task for use with manage.
+func ignoreContext[Input, Output any](task Task[Input, Output]) CTask[Input, Output] {
+	return func(_ context.Context, in Input) (Output, error) {
+		return task(in)
+	}
+}
+
+// manage runs the task management loop with a TaskPool of numWorkers workers.
+// Each task receives a child context of ctx
+// which is canceled once manage is ready to return.
+// Panicking tasks are reported as a *PanicError
+// and a halt by the manager is reported as ErrStopped.
+func manage[Input, Output any](ctx context.Context, numWorkers int, task CTask[Input, Output], manager Manager[Input, Output], q queue[Input], opts ...Option) error {
+	return manageFunc(ctx, numWorkers, task, fromManager(manager), q, opts...)
+}
+
+// manageFunc is like manage, but it takes a managerFunc.
+func manageFunc[Input, Output any](ctx context.Context, numWorkers int, task CTask[Input, Output], manager managerFunc[Input, Output], q queue[Input], opts ...Option) (err error) {
+	o := buildOptions(opts)
+	obs := observerFor[Input](&o)
+	if t, ok := q.(tunable); ok {
+		t.tune(o.initialQueueCap, o.shrinkQueue)
+	}
+	// only a plain fifo, since other queues depend on their order
+	if f, ok := q.(*fifo[Input]); ok && o.shuffleInitial {
+		f.shuffle(o.shuffleSeed)
+	}
+	if o.spillDir != "" {
+		sq := newSpill(q, o.spillDir, o.spillThreshold)
+		defer func() {
+			if cerr := sq.close(); err == nil {
+				err = cerr
+			}
+		}()
+		q = sq
+	}
+	if o.fairExternal > 0 && o.source != nil {
+		q = newFair(q, o.fairExternal, o.fairInternal)
+	}
+	parentDone := ctx.Done()
+	ctx, cancel := context.WithCancel(ctx)
+	wrap := func(task CTask[Input, Output]) Task[Input, Output] {
+		return withOnComplete(&o, func(in Input) (Output, error) {
+			obs.OnStart(in)
+			return task(ctx, in)
+		})
+	}
+	run := wrap(task)
+	newWorker, _ := o.worker.(func(int) (CTask[Input, Output], func()))
+	if o.synchronous {
+		if newWorker != nil {
+			t, exit := newWorker(0)
+			defer exit()
+			run = wrap(t)
+		}
+		return newLoop(&o, nil, nil, manager, q, obs).runSync(ctx, cancel, run)
+	}
+	if numWorkers < 1 {
+		numWorkers = runtime.GOMAXPROCS(0)
+	}
+	if o.scaleMax > 0 {
+		numWorkers = o.scaleMax
+	}
+	// Workers are started as inputs are dispatched,
+	// so a large numWorkers costs nothing for a small batch.
+	pool := newWorkerPool(numWorkers, o.outputBuffer, !o.noRecover, run)
+	pool.opts = &o
+	if newWorker != nil {
+		pool.worker = func(id int) (Task[Input, Output], func()) {
+			t, exit := newWorker(id)
+			return wrap(t), exit
+		}
+	}
+	in, out := pool.in, pool.out
+	l := newLoop(&o, in, out, manager, q, obs)
+	l.workers = numWorkers
+	l.pool = pool
+	l.limit = o.scaleMin
+	if o.drainOnCancel {
+		l.parentDone = parentDone
+	}
 	defer func() {
 		close(in)
-		// drain any waiting tasks
+		go pool.wait()
+		if l.abandoned {
+			// leave the stuck workers to exit in the background
+			go func() {
+				for range out {
+				}
+			}()
+			return
+		}
+		// Wait for the workers to exit.
+		// The pool closes out only after every worker has returned,
+		// so no worker outlives manageFunc.
 		for range out {
 		}
 	}()
-	queue := deque.Of(initial...)
-	inflight := 0
-	for inflight > 0 || queue.Len() > 0 {
-		inch := in
-		item, ok := queue.Head()
-		if !ok {
-			inch = nil
-		}
-		select {
-		case inch <- item:
-			inflight++
-			queue.RemoveFront()
-		case r := <-out:
-			inflight--
-			if r.Panic != nil {
-				panic(r.Panic)
-			}
-			items, ok := manager(r.In, r.Out, r.Err)
-			if !ok {
-				return
-			}
-			queue.PushBackSlice(items)
-		}
-	}
+	return l.run(ctx, cancel)
 }
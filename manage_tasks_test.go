@@ -1,8 +1,15 @@
 package flowmatic_test
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -46,3 +53,1091 @@ func TestManageTasks_drainage(t *testing.T) {
 		t.Fatal("didn't finish")
 	}
 }
+
+func TestManageTasksContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var finished atomic.Int64
+	task := func(n int) (int, error) {
+		if n == 0 {
+			cancel()
+			return 0, nil
+		}
+		time.Sleep(10 * time.Millisecond)
+		finished.Add(1)
+		return n, nil
+	}
+	calls := 0
+	manager := func(in, out int, err error) ([]int, bool) {
+		calls++
+		return []int{in + 10}, true
+	}
+	err := flowmatic.ManageTasksContext(ctx, 2, task, manager, 1, 0)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatal(err)
+	}
+	// the in-flight task was drained
+	if finished.Load() != 1 {
+		t.Fatal(finished.Load())
+	}
+	if calls > 1 {
+		t.Fatal(calls)
+	}
+}
+
+func TestManageTasksErr(t *testing.T) {
+	bad := errors.New("bad")
+	task := func(n int) (int, error) {
+		switch n {
+		case 2:
+			return 0, bad
+		case 3:
+			panic("boom")
+		}
+		return n, nil
+	}
+	manager := func(in, out int, err error) ([]int, bool) {
+		if err != nil {
+			return nil, false
+		}
+		return []int{in + 1}, true
+	}
+	err := flowmatic.ManageTasksErr(1, task, manager, 0)
+	if !errors.Is(err, flowmatic.ErrStopped) || !errors.Is(err, bad) {
+		t.Fatal(err)
+	}
+	manager = func(in, out int, err error) ([]int, bool) {
+		return []int{in + 1}, true
+	}
+	err = flowmatic.ManageTasksErr(1, task, manager, 0)
+	var pe *flowmatic.PanicError
+	if !errors.As(err, &pe) {
+		t.Fatal(err)
+	}
+	if pe.Value != "boom" || len(pe.Stack) == 0 {
+		t.Fatal(pe.Value, string(pe.Stack))
+	}
+}
+
+func TestManageTasksPriority(t *testing.T) {
+	task := func(n int) (int, error) {
+		return n, nil
+	}
+	var order []int
+	manager := func(in, out int, err error) ([]int, bool) {
+		order = append(order, in)
+		if in == 5 {
+			return []int{9, 1, 7, 3}, true
+		}
+		return nil, true
+	}
+	less := func(a, b int) bool { return a < b }
+	flowmatic.ManageTasksPriority(1, less, task, manager, 8, 5, 6)
+	// 5 is dispatched first, but 6 may be in flight
+	// before the children of 5 are queued.
+	if s := fmt.Sprint(order); s != "[5 6 1 3 7 8 9]" && s != "[5 1 3 6 7 8 9]" {
+		t.Fatal(s)
+	}
+}
+
+func TestManageTasksSet(t *testing.T) {
+	task := func(n int) (int, error) {
+		return n, nil
+	}
+	var seen []int
+	manager := func(in, out int, err error) ([]int, bool) {
+		seen = append(seen, in)
+		// the graph n -> n+1, n+2 revisits most nodes
+		if in < 5 {
+			return []int{in + 1, in + 2}, true
+		}
+		return nil, true
+	}
+	flowmatic.ManageTasksSet(1, task, manager, 0, 0)
+	slices.Sort(seen)
+	if s := fmt.Sprint(seen); s != "[0 1 2 3 4 5 6]" {
+		t.Fatal(s)
+	}
+}
+
+func TestManageTasksDedupBy(t *testing.T) {
+	type page struct {
+		url   string
+		links []string
+	}
+	task := func(p page) (int, error) {
+		return len(p.links), nil
+	}
+	var seen []string
+	manager := func(in page, out int, err error) ([]page, bool) {
+		seen = append(seen, in.url)
+		var next []page
+		for _, link := range in.links {
+			next = append(next, page{url: link})
+		}
+		return next, true
+	}
+	key := func(p page) string {
+		return strings.TrimSuffix(strings.ToLower(p.url), "/")
+	}
+	flowmatic.ManageTasksDedupBy(1, key, task, manager,
+		page{"a", []string{"B", "b/", "c"}},
+		page{"A/", []string{"d"}})
+	if s := fmt.Sprint(seen); s != "[a B c]" {
+		t.Fatal(s)
+	}
+}
+
+func TestManageTasksWith_maxQueue(t *testing.T) {
+	const maxQueue = 5
+	var started atomic.Int64
+	task := func(_ context.Context, n int) (int, error) {
+		started.Add(1)
+		return n, nil
+	}
+	produced, calls := 1, 0
+	manager := func(in, out int, err error) ([]int, bool) {
+		calls++
+		// the worker may not have counted the latest dispatch yet
+		if queued := produced - int(started.Load()); queued > maxQueue {
+			t.Errorf("queue too long: %d", queued)
+		}
+		if in >= 3 {
+			return nil, true
+		}
+		children := []int{in + 1, in + 1, in + 1, in + 1}
+		produced += len(children)
+		return children, true
+	}
+	err := flowmatic.ManageTasksWith(context.Background(), 1, task, manager, []int{0}, flowmatic.MaxQueue(maxQueue))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1+4+16+64 {
+		t.Fatal(calls)
+	}
+}
+
+func TestManageCTasks(t *testing.T) {
+	var canceled atomic.Bool
+	task := func(ctx context.Context, n int) (int, error) {
+		if n == 0 {
+			return 0, errors.New("stop")
+		}
+		if !sleepFor(ctx, time.Second) {
+			canceled.Store(true)
+		}
+		return n, nil
+	}
+	manager := func(in, out int, err error) ([]int, bool) {
+		return nil, err == nil
+	}
+	start := time.Now()
+	err := flowmatic.ManageCTasks(context.Background(), 2, task, manager, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !canceled.Load() || time.Since(start) > 500*time.Millisecond {
+		t.Fatal("running task was not canceled")
+	}
+}
+
+func TestManageTasksTimed(t *testing.T) {
+	const numWorkers = 3
+	task := func(d time.Duration) (time.Duration, error) {
+		time.Sleep(d)
+		return d, nil
+	}
+	workers := map[int]bool{}
+	manager := func(r flowmatic.Result[time.Duration, time.Duration]) ([]time.Duration, bool) {
+		if r.Duration < r.In {
+			t.Errorf("duration %v < %v", r.Duration, r.In)
+		}
+		if r.WorkerID < 0 || r.WorkerID >= numWorkers {
+			t.Errorf("bad worker id %d", r.WorkerID)
+		}
+		workers[r.WorkerID] = true
+		return nil, true
+	}
+	flowmatic.ManageTasksTimed(numWorkers, task, manager,
+		time.Millisecond, 2*time.Millisecond, 3*time.Millisecond, 5*time.Millisecond)
+	if len(workers) < 2 {
+		t.Fatal(workers)
+	}
+}
+
+func TestManageTasksWith_maxTasks(t *testing.T) {
+	var started atomic.Int64
+	task := func(_ context.Context, n int) (int, error) {
+		started.Add(1)
+		return n, nil
+	}
+	calls := 0
+	manager := func(in, out int, err error) ([]int, bool) {
+		calls++
+		// an infinite crawl
+		return []int{in + 1, in + 2}, true
+	}
+	err := flowmatic.ManageTasksWith(context.Background(), 4, task, manager, []int{0}, flowmatic.MaxTasks(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 100 || started.Load() != 100 {
+		t.Fatal(calls, started.Load())
+	}
+}
+
+func TestManageTasksWith_maxTasksMaxQueue(t *testing.T) {
+	task := func(_ context.Context, n int) (int, error) {
+		return n, nil
+	}
+	var seen []int
+	manager := func(in, out int, err error) ([]int, bool) {
+		seen = append(seen, in)
+		return nil, true
+	}
+	// the queue stays full once MaxTasks stops dispatching
+	err := flowmatic.ManageTasksWith(context.Background(), 1, task, manager, []int{0, 1, 2, 3},
+		flowmatic.MaxQueue(1), flowmatic.MaxTasks(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(seen, []int{0, 1}) {
+		t.Fatal(seen)
+	}
+}
+
+func TestManageTasksDeadline(t *testing.T) {
+	task := func(n int) (int, error) {
+		time.Sleep(time.Millisecond)
+		return n, nil
+	}
+	manager := func(in, out int, err error) ([]int, bool) {
+		// never finishes on its own
+		return []int{in + 1}, true
+	}
+	start := time.Now()
+	err := flowmatic.ManageTasksDeadline(start.Add(20*time.Millisecond), 2, task, manager, 0)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d > 500*time.Millisecond {
+		t.Fatal(d)
+	}
+}
+
+func TestManageTasksWith_outputBuffer(t *testing.T) {
+	task := func(_ context.Context, n int) (int, error) {
+		return n * 2, nil
+	}
+	sum := 0
+	manager := func(in, out int, err error) ([]int, bool) {
+		sum += out
+		return nil, true
+	}
+	err := flowmatic.ManageTasksWith(context.Background(), 4, task, manager,
+		[]int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, flowmatic.OutputBuffer(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum != 110 {
+		t.Fatal(sum)
+	}
+}
+
+func BenchmarkManageTasksWith_outputBuffer(b *testing.B) {
+	inputs := make([]int, 1000)
+	for i := range inputs {
+		inputs[i] = i
+	}
+	// microtasks which complete in bursts
+	task := func(_ context.Context, n int) (int, error) {
+		if n%16 == 0 {
+			time.Sleep(50 * time.Microsecond)
+		}
+		return n, nil
+	}
+	// a manager which is slow relative to the tasks
+	manager := func(in, out int, err error) ([]int, bool) {
+		deadline := time.Now().Add(time.Microsecond)
+		for time.Now().Before(deadline) {
+		}
+		return nil, true
+	}
+	for _, size := range []int{0, 64, 1024} {
+		b.Run(fmt.Sprintf("buffer-%d", size), func(b *testing.B) {
+			for range b.N {
+				_ = flowmatic.ManageTasksWith(context.Background(), 8, task, manager,
+					inputs, flowmatic.OutputBuffer(size))
+			}
+		})
+	}
+}
+
+func TestManageTasksWith_synchronous(t *testing.T) {
+	before := runtime.NumGoroutine()
+	task := func(_ context.Context, n int) (int, error) {
+		if g := runtime.NumGoroutine(); g != before {
+			t.Errorf("started Goroutines: %d != %d", g, before)
+		}
+		if n == 100 {
+			panic("100!!")
+		}
+		return n * 2, nil
+	}
+	var seen []int
+	manager := func(in, out int, err error) ([]int, bool) {
+		seen = append(seen, out)
+		if in < 10 {
+			return []int{in * 10, in*10 + 1}, true
+		}
+		return nil, true
+	}
+	err := flowmatic.ManageTasksWith(context.Background(), 8, task, manager,
+		[]int{1, 2}, flowmatic.Synchronous())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := fmt.Sprint(seen); s != "[2 4 20 22 40 42]" {
+		t.Fatal(s)
+	}
+	r := try(func() {
+		_ = flowmatic.ManageTasksWith(context.Background(), 8, task, manager,
+			[]int{1, 100, 2}, flowmatic.Synchronous())
+	})
+	if in, v := taskPanic(r); in != 100 || v != "100!!" {
+		t.Fatal(r)
+	}
+}
+
+func TestManageTasksWith_progress(t *testing.T) {
+	task := func(_ context.Context, n int) (int, error) {
+		time.Sleep(time.Millisecond)
+		return n, nil
+	}
+	manager := func(in, out int, err error) ([]int, bool) {
+		return nil, true
+	}
+	var (
+		reports  int
+		lastDone int
+	)
+	report := func(done, inflight, queued int) {
+		reports++
+		// in-flight tasks include results buffered for the manager
+		if done < lastDone || inflight > 4 || done+inflight+queued > 50 {
+			t.Errorf("bad report: %d %d %d", done, inflight, queued)
+		}
+		lastDone = done
+	}
+	inputs := make([]int, 50)
+	err := flowmatic.ManageTasksWith(context.Background(), 2, task, manager, inputs,
+		flowmatic.Progress(5*time.Millisecond, report))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reports < 2 || lastDone != 50 {
+		t.Fatal(reports, lastDone)
+	}
+}
+
+func TestManageTasksWith_circuitBreaker(t *testing.T) {
+	bad := errors.New("bad")
+	task := func(_ context.Context, n int) (int, error) {
+		if n%10 == 0 {
+			return n, bad
+		}
+		return n, nil
+	}
+	calls := 0
+	manager := func(in, out int, err error) ([]int, bool) {
+		calls++
+		return nil, true
+	}
+	// two errors in a row never trip
+	err := flowmatic.ManageTasksWith(context.Background(), 1, task, manager,
+		[]int{0, 10, 1, 20, 30, 2}, flowmatic.CircuitBreaker(3, 0))
+	if err != nil || calls != 6 {
+		t.Fatal(err, calls)
+	}
+	calls = 0
+	err = flowmatic.ManageTasksWith(context.Background(), 1, task, manager,
+		[]int{0, 10, 1, 20, 30, 40, 2}, flowmatic.CircuitBreaker(3, 0))
+	if !errors.Is(err, flowmatic.ErrCircuitOpen) || !errors.Is(err, bad) || calls != 6 {
+		t.Fatal(err, calls)
+	}
+}
+
+func TestManageTasksWith_circuitBreakerCooldown(t *testing.T) {
+	var attempts atomic.Int64
+	bad := errors.New("bad")
+	task := func(_ context.Context, n int) (int, error) {
+		// fail until the breaker trips
+		if attempts.Add(1) <= 2 {
+			return n, bad
+		}
+		return n, nil
+	}
+	tries := map[int]int{}
+	manager := func(in, out int, err error) ([]int, bool) {
+		tries[in]++
+		if err != nil {
+			return []int{in}, true
+		}
+		return nil, true
+	}
+	start := time.Now()
+	err := flowmatic.ManageTasksWith(context.Background(), 1, task, manager,
+		[]int{1, 2, 3}, flowmatic.CircuitBreaker(2, 20*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d < 20*time.Millisecond {
+		t.Fatal("did not cool down", d)
+	}
+	if tries[1] != 2 || tries[2] != 2 || tries[3] != 1 {
+		t.Fatal(tries)
+	}
+}
+
+func BenchmarkManageTasks_smallBatch(b *testing.B) {
+	b.ReportAllocs()
+	inputs := make([]int, 200)
+	task := func(n int) (int, error) {
+		return n, nil
+	}
+	manager := func(in, out int, err error) ([]int, bool) {
+		return nil, true
+	}
+	for range b.N {
+		flowmatic.ManageTasks(4, task, manager, inputs...)
+	}
+}
+
+func TestManageTasksWith_drainTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	task := func(_ context.Context, n int) (int, error) {
+		if n == 1 {
+			// a task which ignores cancelation
+			<-release
+		}
+		return n, nil
+	}
+	manager := func(in, out int, err error) ([]int, bool) {
+		return nil, false
+	}
+	start := time.Now()
+	err := flowmatic.ManageTasksWith(context.Background(), 2, task, manager,
+		[]int{1, 2}, flowmatic.DrainTimeout(10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatal("waited for stuck task", d)
+	}
+}
+
+func TestManageTasksWith_spillTo(t *testing.T) {
+	dir := t.TempDir()
+	type item struct {
+		N    int
+		Path string
+	}
+	task := func(_ context.Context, it item) (int, error) {
+		return it.N, nil
+	}
+	var order []int
+	maxFiles := 0
+	manager := func(in item, out int, err error) ([]item, bool) {
+		order = append(order, out)
+		entries, _ := os.ReadDir(dir)
+		maxFiles = max(maxFiles, len(entries))
+		if in.N == 0 {
+			items := make([]item, 100)
+			for i := range items {
+				items[i] = item{i + 1, fmt.Sprint(i + 1)}
+			}
+			return items, true
+		}
+		return nil, true
+	}
+	err := flowmatic.ManageTasksWith(context.Background(), 1, task, manager,
+		[]item{{0, "0"}}, flowmatic.SpillTo(dir, 10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.IsSorted(order) || len(order) != 101 {
+		t.Fatal(order)
+	}
+	if maxFiles != 1 {
+		t.Fatal("should have spilled to disk")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Fatal("spill file left behind", entries)
+	}
+	// channels cannot be encoded
+	err = flowmatic.ManageTasksWith(context.Background(), 1,
+		func(_ context.Context, c chan int) (int, error) { return 0, nil },
+		func(in chan int, out int, err error) ([]chan int, bool) {
+			return []chan int{make(chan int), make(chan int)}, true
+		},
+		[]chan int{nil}, flowmatic.SpillTo(dir, 1))
+	if err == nil {
+		t.Fatal("should fail to encode")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Fatal("spill file left behind", entries)
+	}
+}
+
+func TestManageTasksWith_stopOn(t *testing.T) {
+	stop := make(chan struct{})
+	task := func(ctx context.Context, n int) (int, error) {
+		time.Sleep(time.Millisecond)
+		return n, nil
+	}
+	calls := 0
+	manager := func(in, out int, err error) ([]int, bool) {
+		calls++
+		if calls == 5 {
+			close(stop)
+		}
+		// an infinite crawl
+		return []int{in + 1}, true
+	}
+	err := flowmatic.ManageTasksWith(context.Background(), 2, task, manager,
+		[]int{0, 0}, flowmatic.StopOn(stop))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// results already received may be handled before the stop is seen
+	if calls < 5 || calls > 7 {
+		t.Fatal(calls)
+	}
+}
+
+func TestManageTasksShuffle(t *testing.T) {
+	task := func(n int) (int, error) {
+		return n, nil
+	}
+	order := func(seed int64) []int {
+		var seen []int
+		manager := func(in, out int, err error) ([]int, bool) {
+			seen = append(seen, in)
+			return nil, true
+		}
+		flowmatic.ManageTasksShuffle(seed, 1, task, manager, 0, 1, 2, 3, 4, 5, 6, 7, 8, 9)
+		return seen
+	}
+	a, b := order(1), order(1)
+	if !slices.Equal(a, b) {
+		t.Fatal("same seed should give the same order", a, b)
+	}
+	sorted := slices.Sorted(slices.Values(a))
+	if !slices.Equal(sorted, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}) {
+		t.Fatal(a)
+	}
+	shuffled := false
+	for seed := range int64(10) {
+		if !slices.IsSorted(order(seed)) {
+			shuffled = true
+		}
+	}
+	if !shuffled {
+		t.Fatal("order should be shuffled")
+	}
+}
+
+func TestManageTasksWith_rampUp(t *testing.T) {
+	var (
+		mu                   sync.Mutex
+		running, early, peak int
+	)
+	start := time.Now()
+	task := func(_ context.Context, n int) (int, error) {
+		mu.Lock()
+		running++
+		if time.Since(start) < 40*time.Millisecond {
+			early = max(early, running)
+		}
+		peak = max(peak, running)
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return n, nil
+	}
+	calls := 0
+	manager := func(in, out int, err error) ([]int, bool) {
+		calls++
+		return nil, true
+	}
+	inputs := make([]int, 60)
+	err := flowmatic.ManageTasksWith(context.Background(), 4, task, manager,
+		inputs, flowmatic.RampUp(200*time.Millisecond))
+	if err != nil || calls != 60 {
+		t.Fatal(err, calls)
+	}
+	// only one worker is available in the first quarter of the ramp up
+	if early != 1 {
+		t.Fatal("too many tasks early in the ramp up:", early)
+	}
+	if peak != 4 {
+		t.Fatal("ramp up never reached every worker:", peak)
+	}
+}
+
+func TestManageTasksWith_rampUpShort(t *testing.T) {
+	// a ramp up shorter than one nanosecond per worker
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	task := func(_ context.Context, n int) (int, error) {
+		clock.Advance(time.Second)
+		return n, nil
+	}
+	calls := 0
+	manager := func(in, out int, err error) ([]int, bool) {
+		calls++
+		return nil, true
+	}
+	inputs := make([]int, 20)
+	err := flowmatic.ManageTasksWith(context.Background(), 4, task, manager,
+		inputs, flowmatic.RampUp(3*time.Nanosecond), flowmatic.WithClock(clock))
+	if err != nil || calls != 20 {
+		t.Fatal(err, calls)
+	}
+}
+
+func TestManageTasksWith_autoScale(t *testing.T) {
+	peakFor := func(sleep, target time.Duration) int {
+		var (
+			mu            sync.Mutex
+			running, peak int
+		)
+		task := func(_ context.Context, n int) (int, error) {
+			mu.Lock()
+			running++
+			peak = max(peak, running)
+			mu.Unlock()
+			time.Sleep(sleep)
+			mu.Lock()
+			running--
+			mu.Unlock()
+			return n, nil
+		}
+		calls := 0
+		manager := func(in, out int, err error) ([]int, bool) {
+			calls++
+			return nil, true
+		}
+		err := flowmatic.ManageTasksWith(context.Background(), 1, task, manager,
+			make([]int, 30), flowmatic.AutoScale(1, 4, target))
+		if err != nil || calls != 30 {
+			t.Fatal(err, calls)
+		}
+		return peak
+	}
+	if peak := peakFor(time.Millisecond, time.Second); peak != 4 {
+		t.Fatal("fast tasks should scale up:", peak)
+	}
+	if peak := peakFor(2*time.Millisecond, time.Microsecond); peak != 1 {
+		t.Fatal("slow tasks should not scale up:", peak)
+	}
+}
+
+func TestManageTasksWith_stallTimeout(t *testing.T) {
+	ready := make(chan struct{})
+	task := func(ctx context.Context, n int) (int, error) {
+		if n == 2 {
+			close(ready)
+			return n, nil
+		}
+		// waits on a task which can never be dispatched
+		select {
+		case <-ready:
+			return n, nil
+		case <-ctx.Done():
+			return n, ctx.Err()
+		}
+	}
+	manager := func(in, out int, err error) ([]int, bool) {
+		return nil, true
+	}
+	start := time.Now()
+	err := flowmatic.ManageTasksWith(context.Background(), 2, task, manager,
+		[]int{0, 1, 2}, flowmatic.StallTimeout(20*time.Millisecond))
+	if !errors.Is(err, flowmatic.ErrPossibleStall) {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatal(d)
+	}
+	// steady progress does not trip it
+	task = func(_ context.Context, n int) (int, error) {
+		time.Sleep(5 * time.Millisecond)
+		return n, nil
+	}
+	err = flowmatic.ManageTasksWith(context.Background(), 2, task, manager,
+		make([]int, 20), flowmatic.StallTimeout(100*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestManageTasksWith_errSkip(t *testing.T) {
+	task := func(_ context.Context, n int) (int, error) {
+		if n%2 == 1 {
+			return 0, fmt.Errorf("odd: %w", flowmatic.ErrSkip)
+		}
+		return n, nil
+	}
+	var seen, skipped []int
+	manager := func(in, out int, err error) ([]int, bool) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen = append(seen, in)
+		return nil, true
+	}
+	onSkip := func(n int) {
+		skipped = append(skipped, n)
+	}
+	err := flowmatic.ManageTasksWith(context.Background(), 2, task, manager,
+		[]int{0, 1, 2, 3, 4}, flowmatic.OnSkip(onSkip))
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(seen)
+	slices.Sort(skipped)
+	if fmt.Sprint(seen, skipped) != "[0 2 4] [1 3]" {
+		t.Fatal(seen, skipped)
+	}
+	// skipped results do not hold up ordered results
+	seen, skipped = nil, nil
+	err = flowmatic.ManageTasksOrderedWith(context.Background(), 2, task, manager,
+		[]int{0, 1, 2, 3, 4}, flowmatic.OnSkip(onSkip))
+	if err != nil || fmt.Sprint(seen, skipped) != "[0 2 4] [1 3]" {
+		t.Fatal(err, seen, skipped)
+	}
+}
+
+func TestManageTasksWith_maxTasksSkip(t *testing.T) {
+	started := 0
+	task := func(_ context.Context, n int) (int, error) {
+		started++
+		if n == 0 {
+			return 0, flowmatic.ErrSkip
+		}
+		return n, nil
+	}
+	var seen []int
+	manager := func(in, out int, err error) ([]int, bool) {
+		seen = append(seen, in)
+		return nil, true
+	}
+	for _, opts := range [][]flowmatic.Option{
+		{flowmatic.MaxTasks(2)},
+		{flowmatic.MaxTasks(2), flowmatic.Synchronous()},
+	} {
+		started, seen = 0, nil
+		// the skipped task counts toward the limit
+		err := flowmatic.ManageTasksWith(context.Background(), 1, task, manager, []int{0, 1, 2, 3}, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if started != 2 || !slices.Equal(seen, []int{1}) {
+			t.Fatal(started, seen)
+		}
+	}
+}
+
+func TestManageTasksWith_onQuiescent(t *testing.T) {
+	task := func(_ context.Context, n int) (int, error) {
+		return n, nil
+	}
+	var seen []int
+	manager := func(in, out int, err error) ([]int, bool) {
+		seen = append(seen, in)
+		if in%10 < 2 {
+			return []int{in + 1}, true
+		}
+		return nil, true
+	}
+	var phases []int
+	onQuiescent := func() []int {
+		phases = append(phases, len(seen))
+		if len(phases) < 3 {
+			return []int{len(phases) * 10}
+		}
+		return nil
+	}
+	err := flowmatic.ManageTasksWith(context.Background(), 2, task, manager,
+		[]int{0}, flowmatic.OnQuiescent(onQuiescent))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// each phase finishes before the next starts
+	if s := fmt.Sprint(seen, phases); s != "[0 1 2 10 11 12 20 21 22] [3 6 9]" {
+		t.Fatal(s)
+	}
+	seen, phases = nil, nil
+	err = flowmatic.ManageTasksOrderedWith(context.Background(), 2, task, manager,
+		[]int{0}, flowmatic.OnQuiescent(onQuiescent))
+	if s := fmt.Sprint(seen, phases); err != nil || s != "[0 1 2 10 11 12 20 21 22] [3 6 9]" {
+		t.Fatal(err, s)
+	}
+}
+
+func TestManageTasks_noExpand(t *testing.T) {
+	var n atomic.Int64
+	task := func(delta int64) (int64, error) {
+		n.Add(delta)
+		return delta, nil
+	}
+	flowmatic.ManageTasks(2, task, flowmatic.NoExpand[int64, int64](), 1, 2, 3)
+	flowmatic.ManageTasks(2, task, nil, 4, 5)
+	if n.Load() != 15 {
+		t.Fatal(n.Load())
+	}
+}
+
+func TestManageTasksWith_drainUntilCanceled(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	ctx, cancel := context.WithCancel(context.Background())
+	task := func(_ context.Context, n int) (int, error) {
+		if n == 1 {
+			cancel()
+			// a task which ignores cancelation
+			<-release
+		}
+		return n, nil
+	}
+	manager := func(in, out int, err error) ([]int, bool) {
+		return nil, true
+	}
+	start := time.Now()
+	err := flowmatic.ManageTasksWith(ctx, 2, task, manager,
+		[]int{1, 2}, flowmatic.DrainUntilCanceled())
+	if err != context.Canceled {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatal("waited for stuck task", d)
+	}
+}
+
+func TestManageTasks_lazyWorkers(t *testing.T) {
+	before := runtime.NumGoroutine()
+	var peak atomic.Int64
+	task := func(n int) (int, error) {
+		g := int64(runtime.NumGoroutine())
+		for {
+			p := peak.Load()
+			if g <= p || peak.CompareAndSwap(p, g) {
+				break
+			}
+		}
+		return n, nil
+	}
+	manager := func(in, out int, err error) ([]int, bool) {
+		return nil, true
+	}
+	flowmatic.ManageTasks(10_000, task, manager, 1, 2, 3)
+	// at most one worker per input is started
+	if p := peak.Load(); p > int64(before+10) {
+		t.Fatal("started idle workers:", before, p)
+	}
+}
+
+func TestManageTasksWith_failFast(t *testing.T) {
+	errBad := errors.New("bad input")
+	var canceled atomic.Int64
+	task := func(ctx context.Context, n int) (int, error) {
+		if n == 3 {
+			return 0, errBad
+		}
+		if n > 3 {
+			<-ctx.Done()
+			canceled.Add(1)
+			return 0, ctx.Err()
+		}
+		return n, nil
+	}
+	var seen []int
+	manager := func(in, out int, err error) ([]int, bool) {
+		if err != nil {
+			t.Error("manager saw error:", err)
+		}
+		seen = append(seen, in)
+		return nil, true
+	}
+	err := flowmatic.ManageTasksWith(context.Background(), 4, task, manager,
+		[]int{1, 2, 4, 5, 3, 6, 7}, flowmatic.FailFast())
+	if err != errBad {
+		t.Fatal(err)
+	}
+	for _, in := range seen {
+		if in != 1 && in != 2 {
+			t.Fatal(seen)
+		}
+	}
+	// 4 and 5 always start before 3 fails,
+	// but a freed worker may also start 6 or 7
+	// before the loop sees the error
+	if n := canceled.Load(); n < 2 {
+		t.Fatal(n)
+	}
+}
+
+func TestManageTasksWith_barrier(t *testing.T) {
+	const barrier = 0
+	for _, synchronous := range []bool{false, true} {
+		var (
+			mu         sync.Mutex
+			phase1Done int
+		)
+		task := func(_ context.Context, n int) (int, error) {
+			if n == barrier {
+				t.Error("barrier given to task")
+			}
+			if n >= 8 && n <= 10 {
+				mu.Lock()
+				if phase1Done < 7 {
+					t.Errorf("%d started with %d of phase 1 done", n, phase1Done)
+				}
+				mu.Unlock()
+			}
+			if n > 10 {
+				mu.Lock()
+				if phase1Done != 10 {
+					t.Errorf("%d started with %d of phase 1 done", n, phase1Done)
+				}
+				mu.Unlock()
+				return n, nil
+			}
+			time.Sleep(time.Duration(n) * time.Millisecond)
+			mu.Lock()
+			phase1Done++
+			mu.Unlock()
+			return n, nil
+		}
+		var seen []int
+		manager := func(in, out int, err error) ([]int, bool) {
+			seen = append(seen, in)
+			if in == 1 {
+				// a second phase queued behind a barrier, then a redundant barrier
+				return []int{barrier, 11, 12, barrier, barrier}, true
+			}
+			return nil, true
+		}
+		opts := []flowmatic.Option{flowmatic.Barrier(func(n int) bool { return n == barrier })}
+		if synchronous {
+			opts = append(opts, flowmatic.Synchronous())
+		}
+		err := flowmatic.ManageTasksWith(context.Background(), 4, task, manager,
+			[]int{1, 2, 3, 4, 5, 6, 7, barrier, 8, 9, 10}, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(seen) != 12 {
+			t.Fatal(seen)
+		}
+	}
+}
+
+func TestManageTasksWith_queueCap(t *testing.T) {
+	const burst = 10_000
+	task := func(_ context.Context, n int) (int, error) {
+		return n, nil
+	}
+	var seen []int
+	manager := func(in, out int, err error) ([]int, bool) {
+		seen = append(seen, in)
+		if in == 0 {
+			items := make([]int, burst)
+			for i := range items {
+				items[i] = i + 1
+			}
+			return items, true
+		}
+		return nil, true
+	}
+	err := flowmatic.ManageTasksWith(context.Background(), 1, task, manager, []int{0},
+		flowmatic.InitialQueueCap(100), flowmatic.ShrinkQueue(), flowmatic.Synchronous())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != burst+1 {
+		t.Fatal(len(seen))
+	}
+	for i, n := range seen {
+		if i != n {
+			t.Fatal(i, n)
+		}
+	}
+}
+
+func TestManageTasksWith_shuffleInitial(t *testing.T) {
+	task := func(_ context.Context, n int) (int, error) {
+		return n, nil
+	}
+	run := func(seed int64) []int {
+		var seen []int
+		manager := func(in, out int, err error) ([]int, bool) {
+			seen = append(seen, in)
+			if in == 0 {
+				return []int{100, 101, 102}, true
+			}
+			return nil, true
+		}
+		err := flowmatic.ManageTasksWith(context.Background(), 1, task, manager,
+			[]int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+			flowmatic.ShuffleInitial(seed), flowmatic.Synchronous())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return seen
+	}
+	a, b := run(1), run(1)
+	if !slices.Equal(a, b) {
+		t.Fatal(a, b)
+	}
+	if s := fmt.Sprint(a[10:]); s != "[100 101 102]" {
+		t.Fatal(a)
+	}
+	initial := slices.Sorted(slices.Values(a[:10]))
+	if s := fmt.Sprint(initial); s != "[0 1 2 3 4 5 6 7 8 9]" || slices.IsSorted(a[:10]) {
+		t.Fatal(a)
+	}
+	if c := run(2); slices.Equal(a, c) {
+		t.Fatal(a, c)
+	}
+}
+
+func TestManageTasks_emptyInitial(t *testing.T) {
+	task := func(n int) (int, error) {
+		t.Error("task called")
+		return n, nil
+	}
+	manager := func(in, out int, err error) ([]int, bool) {
+		t.Error("manager called")
+		return nil, true
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		flowmatic.ManageTasks(4, task, manager)
+		ctask := func(_ context.Context, n int) (int, error) {
+			return task(n)
+		}
+		_ = flowmatic.ManageTasksWith(context.Background(), 4, ctask, manager, nil)
+		flowmatic.ManageTasksGen(4, func() (int, bool) { return 0, false }, task, manager)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("batch run with no inputs did not return")
+	}
+}
@@ -2,14 +2,21 @@ package flowmatic
 
 import (
 	"context"
+	"errors"
 )
 
 // Map starts numWorkers concurrent workers (or GOMAXPROCS workers if numWorkers < 1)
 // and attempts to map the input slice to an output slice.
+// The returned slice is index-aligned with the input slice:
+// results[i] is the output of the task for items[i],
+// regardless of the order in which the tasks complete.
 // Each task receives a child context.
 // The first error or panic returned by a task
 // cancels the child context
 // and halts further task scheduling.
+// Map returns nil results and the errors of every task which failed,
+// joined into a multierror
+// ordered by the position of the item that caused them.
 // If a task panics during execution,
 // the panic will be caught and rethrown in the parent Goroutine.
 func Map[Input, Output any](ctx context.Context, numWorkers int, items []Input, task func(context.Context, Input) (Output, error)) (results []Output, err error) {
@@ -21,7 +28,11 @@ func Map[Input, Output any](ctx context.Context, numWorkers int, items []Input,
 		return task(ctx, item)
 	})
 
-	var panicVal any
+	var (
+		panicVal any
+		failed   bool
+	)
+	errs := make([]error, len(items))
 	n := 0
 	closeinch := false
 	results = make([]Output, len(items))
@@ -42,15 +53,16 @@ func Map[Input, Output any](ctx context.Context, numWorkers int, items []Input,
 				if panicVal != nil {
 					panic(panicVal)
 				}
-				if err != nil {
-					return nil, err
+				if failed {
+					return nil, errors.Join(errs...)
 				}
 				return results, nil
 			}
-			if r.Err != nil && err == nil {
+			if r.Err != nil {
 				cancel()
 				closeinch = true
-				err = r.Err
+				failed = true
+				errs[r.In] = r.Err
 			}
 			if r.Panic != nil && panicVal == nil {
 				cancel()
@@ -61,3 +73,36 @@ func Map[Input, Output any](ctx context.Context, numWorkers int, items []Input,
 		}
 	}
 }
+
+// MapDedup is like Map,
+// but items with the same key run a single task,
+// whose output is shared by every item with that key.
+// The task for a key receives the first item with that key.
+// If the task for a key fails,
+// MapDedup fails as Map does.
+func MapDedup[Input any, Key comparable, Output any](ctx context.Context, numWorkers int, items []Input, keyOf func(Input) Key, task func(context.Context, Input) (Output, error)) ([]Output, error) {
+	var (
+		firsts []Input
+		pos    = make([]int, len(items))
+		seen   = make(map[Key]int)
+	)
+	for i, item := range items {
+		key := keyOf(item)
+		n, ok := seen[key]
+		if !ok {
+			n = len(firsts)
+			seen[key] = n
+			firsts = append(firsts, item)
+		}
+		pos[i] = n
+	}
+	outputs, err := Map(ctx, numWorkers, firsts, task)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]Output, len(items))
+	for i, n := range pos {
+		results[i] = outputs[n]
+	}
+	return results, nil
+}
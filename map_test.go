@@ -3,7 +3,12 @@ package flowmatic_test
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/carlmjohnson/flowmatic"
 )
@@ -22,13 +27,77 @@ func TestMap(t *testing.T) {
 			panic("should be canceled by now!")
 		}
 	})
+	// b may also be reported if item 2 was dispatched
+	// before the error from item 1 was received,
+	// but item 3 is never run.
 	if !errors.Is(errs, a) {
 		t.Fatal(errs)
 	}
-	if errors.Is(errs, b) {
+	if o != nil {
+		t.Fatal(o)
+	}
+}
+
+func TestMap_join_errs(t *testing.T) {
+	ctx := context.Background()
+	a := errors.New("a")
+	b := errors.New("b")
+	var started sync.WaitGroup
+	started.Add(2)
+	o, errs := flowmatic.Map(ctx, 2, []error{a, b}, func(_ context.Context, err error) (int, error) {
+		// make sure both tasks are running before either fails
+		started.Done()
+		started.Wait()
+		return 0, err
+	})
+	if !errors.Is(errs, a) || !errors.Is(errs, b) {
 		t.Fatal(errs)
 	}
 	if o != nil {
 		t.Fatal(o)
 	}
 }
+
+func TestMap_order(t *testing.T) {
+	ctx := context.Background()
+	o, err := flowmatic.Map(ctx, 3, []int{30, 20, 10, 0}, func(_ context.Context, ms int) (int, error) {
+		// later items finish first
+		time.Sleep(time.Duration(ms) * time.Millisecond)
+		return ms, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := fmt.Sprint(o); s != "[30 20 10 0]" {
+		t.Fatal(s)
+	}
+}
+
+func TestMapDedup(t *testing.T) {
+	ctx := context.Background()
+	var calls atomic.Int64
+	words := []string{"a", "bb", "A", "cc", "BB", "a"}
+	o, err := flowmatic.MapDedup(ctx, 3, words, strings.ToLower, func(_ context.Context, s string) (int, error) {
+		calls.Add(1)
+		return len(s), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := fmt.Sprint(o); s != "[1 2 1 2 2 1]" {
+		t.Fatal(s)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Fatal(n)
+	}
+	bad := errors.New("bad")
+	o, err = flowmatic.MapDedup(ctx, 3, words, strings.ToLower, func(_ context.Context, s string) (int, error) {
+		if s == "bb" {
+			return 0, bad
+		}
+		return len(s), nil
+	})
+	if !errors.Is(err, bad) || o != nil {
+		t.Fatal(o, err)
+	}
+}
@@ -0,0 +1,55 @@
+package flowmatic
+
+import (
+	"sync"
+)
+
+// Memoize wraps task so that its output is cached by input.
+// Running the wrapped task again with an input which has already succeeded
+// returns the cached output instead of running task.
+// Concurrent calls with the same input share a single call to task.
+// Errors and panics are given to every call sharing the failed call,
+// but they are not cached,
+// so a later call with the same input tries again.
+// The cache lasts as long as the wrapped task,
+// so call Memoize once per run.
+func Memoize[Input comparable, Output any](task Task[Input, Output]) Task[Input, Output] {
+	type call struct {
+		done  chan struct{}
+		out   Output
+		err   error
+		panic any
+	}
+	var (
+		mu    sync.Mutex
+		calls = make(map[Input]*call)
+	)
+	return func(in Input) (Output, error) {
+		mu.Lock()
+		c, ok := calls[in]
+		if !ok {
+			c = &call{done: make(chan struct{})}
+			calls[in] = c
+		}
+		mu.Unlock()
+		if !ok {
+			func() {
+				defer func() {
+					c.panic = recover()
+					if c.panic != nil || c.err != nil {
+						mu.Lock()
+						delete(calls, in)
+						mu.Unlock()
+					}
+					close(c.done)
+				}()
+				c.out, c.err = task(in)
+			}()
+		}
+		<-c.done
+		if c.panic != nil {
+			panic(c.panic)
+		}
+		return c.out, c.err
+	}
+}
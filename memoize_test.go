@@ -0,0 +1,70 @@
+package flowmatic_test
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+func TestMemoize(t *testing.T) {
+	var calls atomic.Int64
+	fail := true
+	task := flowmatic.Memoize(func(n int) (int, error) {
+		calls.Add(1)
+		if n < 0 {
+			if fail {
+				return 0, errors.New("negative")
+			}
+			return -n, nil
+		}
+		time.Sleep(10 * time.Millisecond)
+		return n * 2, nil
+	})
+	// concurrent first calls are coalesced
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if out, err := task(3); out != 6 || err != nil {
+				t.Error(out, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if out, err := task(3); out != 6 || err != nil {
+		t.Fatal(out, err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatal(n)
+	}
+	// errors are not cached
+	if _, err := task(-1); err == nil {
+		t.Fatal("should fail")
+	}
+	fail = false
+	if out, err := task(-1); out != 1 || err != nil {
+		t.Fatal(out, err)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Fatal(n)
+	}
+
+	// the manager still sees every result
+	var seen int
+	manager := func(in, out int, err error) ([]int, bool) {
+		seen++
+		if seen < 5 {
+			return []int{3}, true
+		}
+		return nil, true
+	}
+	flowmatic.ManageTasks(2, task, manager, 3)
+	if seen != 5 || calls.Load() != 3 {
+		t.Fatal(seen, calls.Load())
+	}
+}
@@ -0,0 +1,27 @@
+package flowmatic
+
+// Middleware wraps a task to add behavior,
+// such as retries, timeouts, or logging.
+// Recovering and Memoize are Middleware,
+// and wrappers which take other arguments first,
+// like Retry and WithTimeout,
+// become Middleware by closing over those arguments.
+type Middleware[Input, Output any] func(Task[Input, Output]) Task[Input, Output]
+
+// Chain combines mws into a single Middleware.
+// The first Middleware is the outermost,
+// so it sees each input first and each result last,
+// as with net/http middleware.
+func Chain[Input, Output any](mws ...Middleware[Input, Output]) Middleware[Input, Output] {
+	return func(task Task[Input, Output]) Task[Input, Output] {
+		for i := len(mws) - 1; i >= 0; i-- {
+			task = mws[i](task)
+		}
+		return task
+	}
+}
+
+// Apply wraps task with mws, the first Middleware being the outermost.
+func Apply[Input, Output any](task Task[Input, Output], mws ...Middleware[Input, Output]) Task[Input, Output] {
+	return Chain(mws...)(task)
+}
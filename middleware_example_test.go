@@ -0,0 +1,50 @@
+package flowmatic_test
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+func ExampleApply() {
+	var log []string
+	logging := func(name string) flowmatic.Middleware[int, int] {
+		return func(task flowmatic.Task[int, int]) flowmatic.Task[int, int] {
+			return func(n int) (int, error) {
+				log = append(log, "enter "+name)
+				defer func() { log = append(log, "exit "+name) }()
+				return task(n)
+			}
+		}
+	}
+	retry := func(task flowmatic.Task[int, int]) flowmatic.Task[int, int] {
+		return flowmatic.Retry(3, nil, nil, task)
+	}
+	timeout := func(task flowmatic.Task[int, int]) flowmatic.Task[int, int] {
+		return flowmatic.WithTimeout(time.Second, task)
+	}
+	tries := 0
+	task := flowmatic.Apply(func(n int) (int, error) {
+		tries++
+		if tries < 2 {
+			return 0, errors.New("flaky")
+		}
+		return n * 2, nil
+	}, logging("outer"), retry, flowmatic.Recovering[int, int], timeout, logging("inner"))
+
+	out, err := task(21)
+	fmt.Println(out, err)
+	for _, line := range log {
+		fmt.Println(line)
+	}
+	// Output:
+	// 42 <nil>
+	// enter outer
+	// enter inner
+	// exit inner
+	// enter inner
+	// exit inner
+	// exit outer
+}
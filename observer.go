@@ -0,0 +1,57 @@
+package flowmatic
+
+import (
+	"fmt"
+	"time"
+)
+
+// Observer receives events from the task management loop of ManageTasksWith.
+// Except for OnStart, its methods are only called
+// from the Goroutine running the loop,
+// so implementations do not need to be safe for concurrent use
+// unless they implement OnStart.
+type Observer[Input any] interface {
+	// OnEnqueue is called when n new inputs have been queued.
+	OnEnqueue(n int)
+	// OnStart is called from a worker Goroutine
+	// just before the task for in runs.
+	OnStart(in Input)
+	// OnDone is called when the result of the task for in is received.
+	// If the task panicked, err is a *PanicError.
+	// It is also called for the results of tasks which were still running
+	// when processing halted, such as because another task panicked,
+	// even though the manager never sees them.
+	OnDone(in Input, err error, dur time.Duration)
+	// OnQueueDepth is called on each pass through the loop
+	// with the current number of queued and in-flight inputs.
+	OnQueueDepth(depth, inflight int)
+}
+
+// Observe reports the events of the task management loop to obs.
+// The Input type of obs must match the Input type of the tasks being managed.
+func Observe[Input any](obs Observer[Input]) Option {
+	return func(o *options) {
+		o.observer = obs
+	}
+}
+
+// observerFor returns the Observer set with Observe
+// or a no-op Observer if none was set.
+func observerFor[Input any](o *options) Observer[Input] {
+	if o.observer == nil {
+		return nopObserver[Input]{}
+	}
+	obs, ok := o.observer.(Observer[Input])
+	if !ok {
+		var in Input
+		panic(fmt.Sprintf("flowmatic: Observer %T cannot observe inputs of type %T", o.observer, in))
+	}
+	return obs
+}
+
+type nopObserver[Input any] struct{}
+
+func (nopObserver[Input]) OnEnqueue(int)                      {}
+func (nopObserver[Input]) OnStart(Input)                      {}
+func (nopObserver[Input]) OnDone(Input, error, time.Duration) {}
+func (nopObserver[Input]) OnQueueDepth(int, int)              {}
@@ -0,0 +1,89 @@
+package flowmatic_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+type countingObserver struct {
+	started           atomic.Int64
+	enqueued, done    int
+	failed            int
+	maxDepth, maxBusy int
+}
+
+func (c *countingObserver) OnEnqueue(n int) { c.enqueued += n }
+func (c *countingObserver) OnStart(int)     { c.started.Add(1) }
+func (c *countingObserver) OnDone(_ int, err error, _ time.Duration) {
+	c.done++
+	if err != nil {
+		c.failed++
+	}
+}
+func (c *countingObserver) OnQueueDepth(depth, inflight int) {
+	c.maxDepth = max(c.maxDepth, depth)
+	c.maxBusy = max(c.maxBusy, inflight)
+}
+
+func TestObserve(t *testing.T) {
+	task := func(_ context.Context, n int) (int, error) {
+		if n%2 == 1 {
+			return 0, errors.New("odd")
+		}
+		return n, nil
+	}
+	manager := func(in, out int, err error) ([]int, bool) {
+		if in == 0 {
+			return []int{1, 2, 3, 4, 5}, true
+		}
+		return nil, true
+	}
+	var obs countingObserver
+	err := flowmatic.ManageTasksWith(context.Background(), 2, task, manager, []int{0},
+		flowmatic.Observe[int](&obs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obs.started.Load() != 6 || obs.enqueued != 6 || obs.done != 6 || obs.failed != 3 {
+		t.Fatal(obs.started.Load(), obs.enqueued, obs.done, obs.failed)
+	}
+	if obs.maxDepth != 5 || obs.maxBusy < 1 || obs.maxBusy > 4 {
+		t.Fatal(obs.maxDepth, obs.maxBusy)
+	}
+}
+
+func TestObserve_panicDrain(t *testing.T) {
+	release := make(chan struct{})
+	task := func(_ context.Context, n int) (int, error) {
+		if n == 0 {
+			time.AfterFunc(10*time.Millisecond, func() { close(release) })
+			panic("boom")
+		}
+		// still running when the panic is received
+		<-release
+		return n, nil
+	}
+	manager := func(in, out int, err error) ([]int, bool) {
+		return nil, true
+	}
+	var obs countingObserver
+	var completed atomic.Int64
+	r := try(func() {
+		_ = flowmatic.ManageTasksWith(context.Background(), 4, task, manager, []int{1, 2, 3, 0},
+			flowmatic.Observe[int](&obs),
+			flowmatic.OnComplete(func(flowmatic.Result[int, int]) {
+				completed.Add(1)
+			}))
+	})
+	if _, v := taskPanic(r); v != "boom" {
+		t.Fatal(r)
+	}
+	if obs.started.Load() != 4 || obs.done != 4 || obs.failed != 1 || completed.Load() != 4 {
+		t.Fatal(obs.started.Load(), obs.done, obs.failed, completed.Load())
+	}
+}
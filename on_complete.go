@@ -0,0 +1,60 @@
+package flowmatic
+
+import (
+	"fmt"
+)
+
+// OnComplete calls fn in the worker Goroutine right after each task returns,
+// whether it succeeded, failed, or panicked,
+// like a deferred call in the task.
+// It can be used to release resources or end tracing spans
+// as soon as a task is done,
+// rather than waiting for its result to reach the manager.
+// The Result given to fn does not have its WorkerID set.
+// After fn returns, a panicking task continues to panic as usual.
+// Because fn runs in the workers,
+// it must be safe for concurrent use.
+// The Input and Output types of fn must match those of the tasks being managed.
+func OnComplete[Input, Output any](fn func(Result[Input, Output])) Option {
+	return func(o *options) {
+		o.onComplete = fn
+	}
+}
+
+// onCompleteFunc returns the function set with OnComplete, or nil if none was set.
+func onCompleteFunc[Input, Output any](o *options) func(Result[Input, Output]) {
+	if o.onComplete == nil {
+		return nil
+	}
+	fn, ok := o.onComplete.(func(Result[Input, Output]))
+	if !ok {
+		panic(fmt.Sprintf("flowmatic: OnComplete function %T cannot handle results of type %T", o.onComplete, Result[Input, Output]{}))
+	}
+	return fn
+}
+
+// withOnComplete wraps task to call the function set with OnComplete, if any.
+func withOnComplete[Input, Output any](o *options, task Task[Input, Output]) Task[Input, Output] {
+	fn := onCompleteFunc[Input, Output](o)
+	if fn == nil {
+		return task
+	}
+	return func(in Input) (out Output, err error) {
+		clock := o.clock()
+		start := clock.Now()
+		defer func() {
+			r := Result[Input, Output]{In: in, Out: out, Err: err}
+			pval := recover()
+			if pval != nil {
+				r.Panic = pval
+				r.Stack = o.captureStack()
+			}
+			r.Duration = clock.Now().Sub(start)
+			fn(r)
+			if pval != nil {
+				panic(pval)
+			}
+		}()
+		return task(in)
+	}
+}
@@ -0,0 +1,64 @@
+package flowmatic_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+func TestManageTasksWith_onComplete(t *testing.T) {
+	task := func(_ context.Context, n int) (int, error) {
+		switch n {
+		case 2:
+			return 0, errors.New("two")
+		case 3:
+			panic("three")
+		}
+		return n * 10, nil
+	}
+	var (
+		mu   sync.Mutex
+		done []string
+	)
+	onComplete := func(r flowmatic.Result[int, int]) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.Panic != nil:
+			if len(r.Stack) == 0 {
+				t.Error("missing stack")
+			}
+			done = append(done, fmt.Sprint(r.In, " panic ", r.Panic))
+		case r.Err != nil:
+			done = append(done, fmt.Sprint(r.In, " err ", r.Err))
+		default:
+			done = append(done, fmt.Sprint(r.In, " out ", r.Out))
+		}
+	}
+	var panics int
+	manager := func(in, out int, err error) ([]int, bool) {
+		var pe *flowmatic.PanicError
+		if errors.As(err, &pe) && pe.Value == "three" {
+			panics++
+		}
+		return nil, true
+	}
+	err := flowmatic.ManageTasksWith(context.Background(), 2, task, manager, []int{1, 2, 3},
+		flowmatic.OnComplete(onComplete), flowmatic.OnPanic(flowmatic.Ignore))
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(done)
+	if s := fmt.Sprint(done); s != "[1 out 10 2 err two 3 panic three]" {
+		t.Fatal(s)
+	}
+	// the panic still reaches the loop
+	if panics != 1 {
+		t.Fatal(panics)
+	}
+}
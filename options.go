@@ -0,0 +1,586 @@
+package flowmatic
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime/debug"
+	"time"
+)
+
+// Option configures the task management loop of ManageTasksWith.
+type Option func(*options)
+
+type options struct {
+	maxQueue         int
+	observer         any
+	collectPanics    bool
+	maxTasks         int
+	outputBuffer     int
+	synchronous      bool
+	progress         func(done, inflight, queued int)
+	progressInterval time.Duration
+	panicPolicy      PanicPolicy
+	breakerErrs      int
+	breakerCooldown  time.Duration
+	pauser           *Pauser
+	drainTimeout     time.Duration
+	drainOnCancel    bool
+	// stats is updated before each call to the manager.
+	stats          *Stats
+	runStats       *RunStats
+	noRecover      bool
+	stackCapture   func() []byte
+	failFast       bool
+	idleTimeout    time.Duration
+	spillDir       string
+	spillThreshold int
+	stop           <-chan struct{}
+	rampUp         time.Duration
+	logger         *slog.Logger
+	reorderMax     int
+	reorderPolicy  OverflowPolicy
+	scaleMin       int
+	scaleMax       int
+	scaleTarget    time.Duration
+	stallTimeout   time.Duration
+	fairExternal   int
+	fairInternal   int
+	// onComplete is a func(Result[Input, Output]).
+	onComplete any
+	// onQuiescent is a func() []Input.
+	onQuiescent any
+	// onSkip is a func(Input).
+	onSkip any
+	// isBarrier is a func(Input) bool.
+	isBarrier any
+	// keepSkips gives the results of tasks which return ErrSkip to the manager.
+	keepSkips bool
+	// deliverPanics gives the results of panicking tasks to the manager
+	// instead of halting.
+	deliverPanics bool
+	// keepCollected gives the results of panics collected under the CollectAndReturn policy
+	// to the manager as well.
+	keepCollected bool
+	// abandon makes drain give up on in-flight tasks once it is closed.
+	abandon <-chan struct{}
+	// source is a <-chan Input of inputs from outside the loop.
+	source any
+	// initialQueueCap and shrinkQueue tune the capacity of the queue.
+	initialQueueCap int
+	shrinkQueue     bool
+	// clk is the Clock set with WithClock.
+	clk Clock
+	// shuffleInitial is set with shuffleSeed by the ShuffleInitial option.
+	shuffleInitial bool
+	shuffleSeed    int64
+	// debug is set by the Debug option.
+	debug bool
+	// demand is sent on when the loop is ready for an input from source.
+	demand chan<- struct{}
+	// leftover is a func(Input) called with the inputs the manager never saw.
+	leftover any
+	// worker is a func(id int) (CTask[Input, Output], func())
+	// returning the task each worker runs and a function to call once it exits.
+	worker any
+}
+
+func buildOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// MaxQueue limits the number of queued inputs to n.
+// While the queue is full,
+// results are held back from the manager
+// until enough queued inputs have been dispatched to make room.
+// Because a single manager call may return any number of inputs,
+// the queue may briefly exceed n by the inputs returned from one call.
+// A limit less than 1 means the queue is unbounded.
+func MaxQueue(n int) Option {
+	return func(o *options) {
+		o.maxQueue = n
+	}
+}
+
+// CollectPanics keeps the panics of tasks
+// which are still running when the first panic is received.
+// If more than one task panicked,
+// the panics are rethrown together as a MultiPanic
+// instead of rethrowing only the first panic.
+func CollectPanics() Option {
+	return func(o *options) {
+		o.collectPanics = true
+	}
+}
+
+// MaxTasks halts processing once n tasks have completed.
+// No more than n tasks are started,
+// so the manager sees the results of exactly n tasks
+// unless the queue is exhausted first.
+// Tasks which return ErrSkip count toward the limit,
+// so the manager sees fewer results if any are skipped.
+// A limit less than 1 means there is no limit.
+func MaxTasks(n int) Option {
+	return func(o *options) {
+		o.maxTasks = n
+	}
+}
+
+// OutputBuffer sets the number of results
+// which workers can deliver before the manager receives them.
+// A larger buffer lets workers finishing in a burst
+// deliver their results without waiting on the manager,
+// which helps when tasks are much faster than the manager.
+// By default, and if n is less than 1,
+// the buffer holds one result per worker.
+func OutputBuffer(n int) Option {
+	return func(o *options) {
+		o.outputBuffer = n
+	}
+}
+
+// Synchronous runs every task inline in the Goroutine running the manager,
+// one at a time in queue order,
+// without starting any worker Goroutines.
+// The manager is called exactly as it would be with a single worker,
+// so runs are deterministic and easy to test,
+// and the stack of a *PanicError is the stack of the panicking task.
+// The number of workers is ignored.
+func Synchronous() Option {
+	return func(o *options) {
+		o.synchronous = true
+	}
+}
+
+// OnSkip calls fn from the Goroutine running the loop
+// with the input of each task which returns ErrSkip,
+// instead of giving its result to the manager.
+// The Input type of fn must match the Input type of the tasks being managed.
+func OnSkip[Input any](fn func(Input)) Option {
+	return func(o *options) {
+		o.onSkip = fn
+	}
+}
+
+// OnQuiescent calls fn from the Goroutine running the loop
+// whenever no inputs are queued and no tasks are running,
+// which would otherwise end the run.
+// It can be used to flush buffers or start the next phase of work.
+// The inputs returned by fn are queued, and processing continues;
+// if fn returns no inputs, the run ends as usual.
+// Once the manager returns DrainAndStop, fn is no longer called.
+// The Input type of fn must match the Input type of the tasks being managed.
+func OnQuiescent[Input any](fn func() []Input) Option {
+	return func(o *options) {
+		o.onQuiescent = fn
+	}
+}
+
+// quiescentFunc returns the function set with OnQuiescent, or nil.
+func quiescentFunc[Input any](o *options) func() []Input {
+	if o.onQuiescent == nil {
+		return nil
+	}
+	fn, ok := o.onQuiescent.(func() []Input)
+	if !ok {
+		var in Input
+		panic(fmt.Sprintf("flowmatic: OnQuiescent function %T cannot return inputs of type %T", o.onQuiescent, in))
+	}
+	return fn
+}
+
+// Barrier divides a run into phases.
+// Queued inputs for which isBarrier returns true are not given to the task.
+// Instead, once a barrier reaches the front of the queue,
+// nothing queued after it is started
+// until every task started before it has finished
+// and its result has been received,
+// and then the barrier is dropped from the queue.
+// The manager can queue a barrier after the inputs of one phase
+// to hold back the inputs of the next.
+// Barriers are passed one at a time in queue order,
+// so several barriers in a row are passed together
+// once the tasks before the first have finished,
+// and a barrier queued while waiting at another
+// is only reached once the queue gets to it.
+// With a queue other than first-in, first-out,
+// a barrier divides the inputs dispatched before it leaves the front of the queue
+// from those dispatched after.
+// IsBarrier is called from the Goroutine running the loop,
+// possibly more than once for the same input.
+// The Input type of isBarrier must match the Input type of the tasks being managed.
+func Barrier[Input any](isBarrier func(Input) bool) Option {
+	return func(o *options) {
+		o.isBarrier = isBarrier
+	}
+}
+
+// barrierFunc returns the function set with Barrier, or nil.
+func barrierFunc[Input any](o *options) func(Input) bool {
+	if o.isBarrier == nil {
+		return nil
+	}
+	fn, ok := o.isBarrier.(func(Input) bool)
+	if !ok {
+		var in Input
+		panic(fmt.Sprintf("flowmatic: Barrier function %T cannot handle inputs of type %T", o.isBarrier, in))
+	}
+	return fn
+}
+
+// skipFunc returns the function set with OnSkip, or nil.
+func skipFunc[Input any](o *options) func(Input) {
+	if o.onSkip == nil {
+		return nil
+	}
+	fn, ok := o.onSkip.(func(Input))
+	if !ok {
+		var in Input
+		panic(fmt.Sprintf("flowmatic: OnSkip function %T cannot handle inputs of type %T", o.onSkip, in))
+	}
+	return fn
+}
+
+// keepSkips makes the loop give the results of tasks which return ErrSkip to the manager,
+// for wrappers which must see every result.
+// The wrapper is responsible for skipping them and calling the OnSkip function.
+func keepSkips() Option {
+	return func(o *options) {
+		o.keepSkips = true
+	}
+}
+
+// deliverPanics makes the loop give the results of panicking tasks to the manager.
+func deliverPanics() Option {
+	return func(o *options) {
+		o.deliverPanics = true
+	}
+}
+
+// keepCollected makes the loop give the results of panicking tasks
+// collected under the CollectAndReturn policy to the manager,
+// for wrappers which must see every result.
+// The wrapper is responsible for dropping them.
+func keepCollected() Option {
+	return func(o *options) {
+		o.keepCollected = true
+	}
+}
+
+// Progress calls report at most once every interval
+// with the number of results given to the manager so far,
+// the number of tasks in flight,
+// and the number of queued inputs.
+// Report is called from the Goroutine running the manager,
+// so the counts are always consistent with what the manager has seen.
+// Report is called once more when processing ends.
+// Progress panics if interval is not positive.
+func Progress(interval time.Duration, report func(done, inflight, queued int)) Option {
+	if interval <= 0 {
+		panic("flowmatic: non-positive interval for Progress")
+	}
+	return func(o *options) {
+		o.progress = report
+		o.progressInterval = interval
+	}
+}
+
+// InitialQueueCap preallocates room for n queued inputs,
+// saving the copies made as the queue grows
+// when a run is known to queue many inputs at once.
+// It applies to the default first-in, first-out queue.
+func InitialQueueCap(n int) Option {
+	return func(o *options) {
+		o.initialQueueCap = n
+	}
+}
+
+// Debug makes the loop check its own bookkeeping on every pass,
+// such as that the number of in-flight tasks is never negative
+// or more than the workers and output buffer can hold,
+// and that it only finishes once nothing is queued or running.
+// A failed check panics with the state of the loop.
+// It is meant for tests of the package and of code which extends it,
+// and it slows the loop down.
+func Debug(on bool) Option {
+	return func(o *options) {
+		o.debug = on
+	}
+}
+
+// ShuffleInitial dispatches the initial inputs of ManageTasksWith
+// in a random order chosen by seed,
+// such as so that a crawler does not always start with the same host.
+// Inputs queued later by the manager are still dispatched first-in, first-out,
+// after the initial inputs.
+// Unlike ManageTasksShuffle, the order is only chosen once,
+// so it adds no cost to dispatching inputs.
+func ShuffleInitial(seed int64) Option {
+	return func(o *options) {
+		o.shuffleInitial = true
+		o.shuffleSeed = seed
+	}
+}
+
+// ShrinkQueue makes the queue release memory as it drains.
+// By default, the queue keeps the capacity of its largest size for the whole run,
+// so a long run with an early burst of inputs holds onto that memory,
+// along with the inputs already dispatched from the unused part of the queue.
+// With ShrinkQueue, once the queue has drained to a quarter of its capacity,
+// the remaining inputs are moved to a smaller queue,
+// but never one smaller than InitialQueueCap.
+// It applies to the default first-in, first-out queue.
+func ShrinkQueue() Option {
+	return func(o *options) {
+		o.shrinkQueue = true
+	}
+}
+
+// FailFast halts processing at the first task error.
+// No new tasks are started,
+// the context of the running tasks is canceled,
+// and ManageTasksWith returns the error once they have finished.
+// The failing result is never given to the manager,
+// so the manager only sees successful results
+// and cannot choose to retry or ignore a failure;
+// it is meant for jobs where any error invalidates the whole run.
+// Because the error never reaches the manager,
+// FailFast should not be combined with CircuitBreaker
+// or with a manager which decides when to halt based on errors.
+// Tasks which return ErrSkip do not trigger it.
+func FailFast() Option {
+	return func(o *options) {
+		o.failFast = true
+	}
+}
+
+// CircuitBreaker trips once k task results in a row are errors;
+// any successful result resets the count.
+// Each failing result is still given to the manager.
+// If cooldown is not positive,
+// tripping halts processing,
+// and ManageTasksWith returns an error wrapping ErrCircuitOpen
+// and the last task error.
+// Otherwise, no new tasks are started until cooldown has passed,
+// and then processing resumes with the queue as it was.
+// A k less than 1 disables the circuit breaker.
+func CircuitBreaker(k int, cooldown time.Duration) Option {
+	return func(o *options) {
+		o.breakerErrs = k
+		o.breakerCooldown = cooldown
+	}
+}
+
+// DrainTimeout limits how long ManageTasksWith waits
+// for running tasks to finish once processing halts.
+// After d has passed,
+// ManageTasksWith returns without waiting for the remaining tasks,
+// whose results and panics are discarded.
+// Their Goroutines are leaked until the tasks return,
+// so DrainTimeout is a last resort against tasks which ignore cancelation.
+// A d that is not positive means there is no limit.
+func DrainTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.drainTimeout = d
+	}
+}
+
+// DrainUntilCanceled stops ManageTasksWith from waiting
+// for running tasks to finish once its context is canceled,
+// so that canceling the context bounds shutdown as well as dispatch.
+// As with DrainTimeout, the results and panics of the remaining tasks are discarded,
+// and tasks which ignore the cancelation of their context leak their Goroutines
+// until they return.
+// Without it, canceling the context stops new tasks from starting,
+// but running tasks are still waited for.
+func DrainUntilCanceled() Option {
+	return func(o *options) {
+		o.drainOnCancel = true
+	}
+}
+
+// RecoverPanics controls whether panicking tasks are recovered.
+// By default they are,
+// and the panic is rethrown in the Goroutine running the manager
+// once the running tasks have finished,
+// or handled according to OnPanic.
+// With RecoverPanics(false),
+// recover is never called in the workers,
+// so a panicking task crashes the program immediately
+// with the stack of the task,
+// without waiting for other tasks or running deferred calls in the caller.
+// This suits tasks which use panics for control flow internally
+// and truly fatal bugs,
+// but it means a single bad input takes down the whole process.
+// It overrides OnPanic.
+func RecoverPanics(recover bool) Option {
+	return func(o *options) {
+		o.noRecover = !recover
+	}
+}
+
+// StackCapture sets the function called
+// to capture the stack of a panicking task or manager
+// for PanicError.Stack and Result.Stack.
+// It is called in the panicking Goroutine before it unwinds,
+// so it can trim or symbolize the result of debug.Stack.
+// The default is debug.Stack.
+func StackCapture(capture func() []byte) Option {
+	return func(o *options) {
+		o.stackCapture = capture
+	}
+}
+
+// captureStack calls the function set with StackCapture, if any,
+// or else debug.Stack.
+func (o *options) captureStack() []byte {
+	if o.stackCapture != nil {
+		return o.stackCapture()
+	}
+	return debug.Stack()
+}
+
+// IdleTimeout halts ManageTasksChanWith
+// once no tasks are queued or running
+// and no inputs have arrived from its inputs channel for d,
+// and ManageTasksChanWith returns ErrIdle.
+// The timeout restarts whenever work arrives.
+// A d that is not positive means there is no limit.
+func IdleTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.idleTimeout = d
+	}
+}
+
+// StallTimeout halts processing with ErrPossibleStall
+// if every worker is busy,
+// inputs are waiting to be dispatched,
+// and no task has finished for d.
+// That is a sign that the running tasks are blocked
+// waiting on something only a queued task could provide,
+// which would otherwise hang forever.
+// Long-running tasks can also trip it,
+// so d should be well above the longest expected task.
+// Running tasks are waited for as usual after halting,
+// so blocked tasks must honor the cancelation of their context,
+// or be given up on with DrainTimeout.
+// A d that is not positive means there is no limit.
+func StallTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.stallTimeout = d
+	}
+}
+
+// SpillTo keeps at most threshold queued inputs in memory.
+// Further inputs are gob encoded to a temporary file in dir
+// and read back in order once the inputs in memory have been dispatched.
+// If dir is empty, os.TempDir is used.
+// Processing halts with an error if an input cannot be encoded or decoded,
+// so Input must be a type that gob can encode;
+// unexported fields are not preserved.
+// The file is removed when processing ends.
+func SpillTo(dir string, threshold int) Option {
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	return func(o *options) {
+		o.spillDir = dir
+		o.spillThreshold = threshold
+	}
+}
+
+// StopOn halts processing once stop is closed or receives a value,
+// just as if the manager had returned false:
+// no new tasks are started,
+// the contexts of running tasks are canceled
+// and the tasks are waited for but their results are discarded,
+// and ManageTasksWith returns nil rather than a context error.
+func StopOn(stop <-chan struct{}) Option {
+	return func(o *options) {
+		o.stop = stop
+	}
+}
+
+// RampUp staggers the availability of the workers over d,
+// so the number of tasks running at once climbs gradually
+// from one to the number of workers
+// instead of every worker hitting a cold downstream at once.
+// The workers do not sleep,
+// so halting during the ramp up does not wait for it to finish.
+// A d that is not positive means there is no ramp up.
+func RampUp(d time.Duration) Option {
+	return func(o *options) {
+		o.rampUp = d
+	}
+}
+
+// AutoScale replaces the fixed number of workers
+// with between minWorkers and maxWorkers workers,
+// adjusted as tasks finish.
+// It starts with minWorkers workers.
+// Each task which finishes within targetLatency
+// while every worker is busy and inputs are waiting adds a worker,
+// and each task which takes longer than targetLatency removes one,
+// on the assumption that slow tasks are a sign of an overloaded downstream.
+// A minWorkers less than 1 is treated as 1,
+// and a maxWorkers less than minWorkers is treated as minWorkers.
+func AutoScale(minWorkers, maxWorkers int, targetLatency time.Duration) Option {
+	return func(o *options) {
+		o.scaleMin = max(minWorkers, 1)
+		o.scaleMax = max(maxWorkers, o.scaleMin)
+		o.scaleTarget = targetLatency
+	}
+}
+
+// withStats makes the loop update stats before each call to the manager.
+func withStats(stats *Stats) Option {
+	return func(o *options) {
+		o.stats = stats
+	}
+}
+
+// withSource makes the loop also queue inputs received from ch
+// and keep running until ch is closed.
+func withSource[Input any](ch <-chan Input) Option {
+	return func(o *options) {
+		o.source = ch
+	}
+}
+
+// withDemand makes the loop send on ch
+// each time it is ready for another input from the channel set with withSource,
+// which is once nothing is queued and a worker is free.
+// The sender on the source channel should wait for a request
+// before taking an input to send.
+func withDemand(ch chan<- struct{}) Option {
+	return func(o *options) {
+		o.demand = ch
+	}
+}
+
+// withLeftovers makes the loop call fn when it finishes
+// with the inputs which were still queued
+// or whose results the manager never received.
+func withLeftovers[Input any](fn func(Input)) Option {
+	return func(o *options) {
+		o.leftover = fn
+	}
+}
+
+// withWorker makes each worker run the task returned by calling fn with its worker ID
+// in place of the task given to the loop,
+// and call the function returned with it once the worker exits.
+func withWorker[Input, Output any](fn func(id int) (CTask[Input, Output], func())) Option {
+	return func(o *options) {
+		o.worker = fn
+	}
+}
+
+// full reports whether the queue has reached its maximum length.
+func (o *options) full(queueLen int) bool {
+	return o.maxQueue > 0 && queueLen >= o.maxQueue
+}
@@ -0,0 +1,192 @@
+package flowmatic
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ManageTasksOrdered is like ManageTasks,
+// but the manager sees results in the order their inputs were queued
+// rather than the order the tasks complete.
+// Results which complete early are buffered
+// until the results of every input queued before them have been given to the manager.
+func ManageTasksOrdered[Input, Output any](numWorkers int, task Task[Input, Output], manager Manager[Input, Output], initial ...Input) {
+	_ = ManageTasksOrderedWith(context.Background(), numWorkers, ignoreContext(task), manager, initial)
+}
+
+// ManageTasksOrderedWith is like ManageTasksOrdered,
+// but it is configured with options like ManageTasksWith.
+// Use the ReorderBuffer option to limit the number of results
+// buffered while waiting for a slow task.
+// A nil manager is treated as NoExpand.
+func ManageTasksOrderedWith[Input, Output any](ctx context.Context, numWorkers int, task CTask[Input, Output], manager Manager[Input, Output], initial []Input, opts ...Option) error {
+	if manager == nil {
+		manager = NoExpand[Input, Output]()
+	}
+	o := buildOptions(opts)
+	onSkip := skipFunc[Input](&o)
+	isBarrier := barrierFunc[Input](&o)
+	seq := 0
+	wrap := func(items []Input) []sequenced[Input] {
+		wrapped := make([]sequenced[Input], len(items))
+		for i, item := range items {
+			// barriers never produce a result to wait for
+			if isBarrier != nil && isBarrier(item) {
+				wrapped[i] = sequenced[Input]{-1, item}
+				continue
+			}
+			wrapped[i] = sequenced[Input]{seq, item}
+			seq++
+		}
+		return wrapped
+	}
+	buf := newReorder[Result[sequenced[Input], Output]]()
+	overflowed := false
+	m := func(r Result[sequenced[Input], Output]) ([]sequenced[Input], Action) {
+		buf.add(r.In.seq, r)
+		var items []sequenced[Input]
+		for {
+			r, ok := buf.pop()
+			if !ok {
+				break
+			}
+			if errors.Is(r.Err, ErrSkip) {
+				if onSkip != nil {
+					onSkip(r.In.val)
+				}
+				continue
+			}
+			// a collected panic only holds the place of its input
+			if r.Panic != nil && o.panicPolicy == CollectAndReturn {
+				continue
+			}
+			newItems, ok := manager(r.In.val, r.Out, r.Err)
+			if !ok {
+				return nil, StopNow
+			}
+			items = append(items, wrap(newItems)...)
+		}
+		if o.reorderMax > 0 && buf.len() > o.reorderMax {
+			overflowed = true
+			return nil, StopNow
+		}
+		return items, Continue
+	}
+	t := func(ctx context.Context, in sequenced[Input]) (Output, error) {
+		return task(ctx, in.val)
+	}
+	items := newFIFO(wrap(initial)...)
+	var q queue[sequenced[Input]] = items
+	if o.reorderMax > 0 && o.reorderPolicy == BlockOnOverflow {
+		q = &window[sequenced[Input]]{fifo: items, size: o.reorderMax + 1, delivered: &buf.next}
+	}
+	opts = append(opts[:len(opts):len(opts)], keepSkips(), keepCollected())
+	if fn := quiescentFunc[Input](&o); fn != nil {
+		opts = append(opts, OnQuiescent(func() []sequenced[Input] {
+			return wrap(fn())
+		}))
+	}
+	if isBarrier != nil {
+		opts = append(opts, Barrier(func(in sequenced[Input]) bool {
+			return in.seq < 0
+		}))
+	}
+	if o.observer != nil {
+		opts = append(opts, Observe[sequenced[Input]](sequencedObserver[Input]{observerFor[Input](&o)}))
+	}
+	if fn := onCompleteFunc[Input, Output](&o); fn != nil {
+		opts = append(opts, OnComplete(func(r Result[sequenced[Input], Output]) {
+			fn(Result[Input, Output]{
+				In:       r.In.val,
+				Out:      r.Out,
+				Err:      r.Err,
+				Panic:    r.Panic,
+				Stack:    r.Stack,
+				Duration: r.Duration,
+				WorkerID: r.WorkerID,
+			})
+		}))
+	}
+	err := rethrow(manageFunc(ctx, numWorkers, t, m, q, opts...))
+	if overflowed {
+		return ErrReorderOverflow
+	}
+	return err
+}
+
+// OverflowPolicy chooses what happens when the buffer set by ReorderBuffer is full.
+type OverflowPolicy int
+
+const (
+	// BlockOnOverflow holds back new inputs
+	// until the oldest result is no longer waiting for a slow task.
+	BlockOnOverflow OverflowPolicy = iota
+	// ErrorOnOverflow halts processing with ErrReorderOverflow.
+	ErrorOnOverflow
+)
+
+// ReorderBuffer limits ManageTasksOrderedWith
+// to buffering n results which completed before a result queued ahead of them.
+// Once the buffer is full, the policy decides what happens.
+// With BlockOnOverflow, no more than n+1 tasks run at once
+// starting from the oldest task whose result has not been given to the manager,
+// so a single slow task throttles the run instead of growing the buffer.
+// An n that is not positive means the buffer is unbounded.
+func ReorderBuffer(n int, policy OverflowPolicy) Option {
+	return func(o *options) {
+		o.reorderMax = n
+		o.reorderPolicy = policy
+	}
+}
+
+// sequenced is a value tagged with its position in a sequence.
+type sequenced[T any] struct {
+	seq int
+	val T
+}
+
+func (s sequenced[T]) unwrapInput() any { return s.val }
+
+// sequencedObserver reports the events for sequenced inputs to an Observer of their values.
+type sequencedObserver[Input any] struct {
+	obs Observer[Input]
+}
+
+func (s sequencedObserver[Input]) OnEnqueue(n int)             { s.obs.OnEnqueue(n) }
+func (s sequencedObserver[Input]) OnStart(in sequenced[Input]) { s.obs.OnStart(in.val) }
+func (s sequencedObserver[Input]) OnDone(in sequenced[Input], err error, dur time.Duration) {
+	s.obs.OnDone(in.val, err, dur)
+}
+func (s sequencedObserver[Input]) OnQueueDepth(depth, inflight int) {
+	s.obs.OnQueueDepth(depth, inflight)
+}
+
+// reorder buffers values tagged with sequence numbers
+// and releases them in sequence order.
+type reorder[T any] struct {
+	next    int
+	pending map[int]T
+}
+
+func newReorder[T any]() *reorder[T] {
+	return &reorder[T]{pending: make(map[int]T)}
+}
+
+func (r *reorder[T]) add(seq int, v T) {
+	r.pending[seq] = v
+}
+
+// pop returns the next value in sequence if it has been added.
+func (r *reorder[T]) pop() (v T, ok bool) {
+	v, ok = r.pending[r.next]
+	if ok {
+		delete(r.pending, r.next)
+		r.next++
+	}
+	return v, ok
+}
+
+func (r *reorder[T]) len() int {
+	return len(r.pending)
+}
@@ -0,0 +1,250 @@
+package flowmatic_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+func TestManageTasks_singleWorkerOrder(t *testing.T) {
+	task := func(n int) (int, error) {
+		return n, nil
+	}
+	var seen []int
+	manager := func(in, out int, err error) ([]int, bool) {
+		seen = append(seen, in)
+		if in < 10 {
+			return []int{in * 10, in*10 + 1}, true
+		}
+		return nil, true
+	}
+	flowmatic.ManageTasks(1, task, manager, 1, 2)
+	if s := fmt.Sprint(seen); s != "[1 2 10 11 20 21]" {
+		t.Fatal(s)
+	}
+}
+
+func TestManageTasksOrdered(t *testing.T) {
+	task := func(d time.Duration) (time.Duration, error) {
+		time.Sleep(d)
+		return d, nil
+	}
+	var seen []time.Duration
+	manager := func(in, out time.Duration, err error) ([]time.Duration, bool) {
+		seen = append(seen, in)
+		if in == 5*time.Millisecond {
+			return []time.Duration{2 * time.Millisecond, 0}, true
+		}
+		return nil, true
+	}
+	flowmatic.ManageTasksOrdered(4, task, manager,
+		5*time.Millisecond, 3*time.Millisecond, time.Millisecond, 0)
+	if s := fmt.Sprint(seen); s != "[5ms 3ms 1ms 0s 2ms 0s]" {
+		t.Fatal(s)
+	}
+}
+
+func TestManageTasksOrdered_nilManager(t *testing.T) {
+	var ran atomic.Int64
+	task := func(n int) (int, error) {
+		ran.Add(1)
+		return n, nil
+	}
+	flowmatic.ManageTasksOrdered[int, int](2, task, nil, 1, 2, 3)
+	if ran.Load() != 3 {
+		t.Fatal(ran.Load())
+	}
+}
+
+func TestManageTasksOrderedWith_reorderBuffer(t *testing.T) {
+	var (
+		mu         sync.Mutex
+		slowDone   bool
+		duringSlow int
+	)
+	task := func(_ context.Context, n int) (int, error) {
+		if n == 0 {
+			time.Sleep(50 * time.Millisecond)
+			mu.Lock()
+			slowDone = true
+			mu.Unlock()
+			return n, nil
+		}
+		mu.Lock()
+		if !slowDone {
+			duringSlow++
+		}
+		mu.Unlock()
+		return n, nil
+	}
+	var seen []int
+	manager := func(in, out int, err error) ([]int, bool) {
+		seen = append(seen, in)
+		return nil, true
+	}
+	inputs := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+	err := flowmatic.ManageTasksOrderedWith(context.Background(), 4, task, manager, inputs,
+		flowmatic.ReorderBuffer(2, flowmatic.BlockOnOverflow))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := fmt.Sprint(seen); s != "[0 1 2 3 4 5 6 7 8 9]" {
+		t.Fatal(s)
+	}
+	// the slow task throttles the run
+	if duringSlow > 2 {
+		t.Fatal("too many tasks ran during the slow task:", duringSlow)
+	}
+
+	slowDone, seen = false, nil
+	err = flowmatic.ManageTasksOrderedWith(context.Background(), 4, task, manager, inputs,
+		flowmatic.ReorderBuffer(2, flowmatic.ErrorOnOverflow))
+	if err != flowmatic.ErrReorderOverflow || len(seen) != 0 {
+		t.Fatal(err, seen)
+	}
+}
+
+func TestManageTasksOrderedWith_reorderBufferMaxQueue(t *testing.T) {
+	task := func(_ context.Context, n int) (int, error) {
+		return n, nil
+	}
+	var seen []int
+	manager := func(in, out int, err error) ([]int, bool) {
+		seen = append(seen, in)
+		return nil, true
+	}
+	// the queue stays full while the window waits for results
+	err := flowmatic.ManageTasksOrderedWith(context.Background(), 2, task, manager, []int{0, 1, 2, 3, 4, 5},
+		flowmatic.ReorderBuffer(1, flowmatic.BlockOnOverflow), flowmatic.MaxQueue(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := fmt.Sprint(seen); s != "[0 1 2 3 4 5]" {
+		t.Fatal(s)
+	}
+}
+
+func TestManageTasksOrderedWith_collectPanics(t *testing.T) {
+	task := func(_ context.Context, n int) (int, error) {
+		if n == 1 {
+			panic(n)
+		}
+		return n, nil
+	}
+	var seen []int
+	manager := func(in, out int, err error) ([]int, bool) {
+		seen = append(seen, in)
+		return nil, true
+	}
+	inputs := []int{0, 1, 2, 3}
+	for _, opts := range [][]flowmatic.Option{
+		{flowmatic.OnPanic(flowmatic.CollectAndReturn)},
+		{flowmatic.OnPanic(flowmatic.CollectAndReturn), flowmatic.ReorderBuffer(1, flowmatic.BlockOnOverflow)},
+	} {
+		seen = nil
+		err := flowmatic.ManageTasksOrderedWith(context.Background(), 2, task, manager, inputs, opts...)
+		var pe *flowmatic.PanicError
+		if !errors.As(err, &pe) || pe.Value != 1 || pe.Input != 1 {
+			t.Fatal(err)
+		}
+		// the collected panic does not hold up later results
+		if s := fmt.Sprint(seen); s != "[0 2 3]" {
+			t.Fatal(s)
+		}
+	}
+}
+
+func TestManageTasksOrderedWith_barrier(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		done int
+	)
+	task := func(_ context.Context, n int) (int, error) {
+		mu.Lock()
+		if n > 3 && done < 3 {
+			t.Errorf("%d started with %d done", n, done)
+		}
+		mu.Unlock()
+		time.Sleep(time.Duration(5-n) * time.Millisecond)
+		mu.Lock()
+		done++
+		mu.Unlock()
+		return n, nil
+	}
+	var seen []int
+	manager := func(in, out int, err error) ([]int, bool) {
+		seen = append(seen, in)
+		return nil, true
+	}
+	err := flowmatic.ManageTasksOrderedWith(context.Background(), 4, task, manager,
+		[]int{1, 2, 3, 0, 4, 5}, flowmatic.Barrier(func(n int) bool { return n == 0 }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := fmt.Sprint(seen); s != "[1 2 3 4 5]" {
+		t.Fatal(s)
+	}
+}
+
+func TestManageTasksOrderedWith_typedOptions(t *testing.T) {
+	task := func(_ context.Context, n int) (int, error) {
+		if n == 2 {
+			return 0, flowmatic.ErrSkip
+		}
+		return n, nil
+	}
+	var seen []int
+	manager := func(in, out int, err error) ([]int, bool) {
+		seen = append(seen, in)
+		return nil, true
+	}
+	var (
+		obs       countingObserver
+		mu        sync.Mutex
+		completed []int
+		skipped   []int
+		quiesced  bool
+	)
+	err := flowmatic.ManageTasksOrderedWith(context.Background(), 3, task, manager,
+		[]int{1, 2, 3, 0, 4, 5},
+		flowmatic.Observe[int](&obs),
+		flowmatic.OnComplete(func(r flowmatic.Result[int, int]) {
+			mu.Lock()
+			defer mu.Unlock()
+			completed = append(completed, r.In)
+		}),
+		flowmatic.OnSkip(func(n int) {
+			skipped = append(skipped, n)
+		}),
+		flowmatic.OnQuiescent(func() []int {
+			if quiesced {
+				return nil
+			}
+			quiesced = true
+			return []int{6}
+		}),
+		flowmatic.Barrier(func(n int) bool { return n == 0 }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := fmt.Sprint(seen); s != "[1 3 4 5 6]" {
+		t.Fatal(s)
+	}
+	if s := fmt.Sprint(skipped); s != "[2]" {
+		t.Fatal(s)
+	}
+	slices.Sort(completed)
+	if s := fmt.Sprint(completed); s != "[1 2 3 4 5 6]" {
+		t.Fatal(s)
+	}
+	if obs.started.Load() != 6 || obs.done != 6 {
+		t.Fatal(obs.started.Load(), obs.done)
+	}
+}
@@ -0,0 +1,31 @@
+package flowmatic
+
+// PanicPolicy controls what the task management loop of ManageTasksWith
+// does when a task panics.
+type PanicPolicy int8
+
+const (
+	// Rethrow halts processing
+	// and rethrows the panic in the parent Goroutine
+	// once the running tasks have finished.
+	// It is the default.
+	Rethrow PanicPolicy = iota
+	// Ignore gives the result of a panicking task to the manager
+	// with a *PanicError as its error,
+	// and processing continues as usual.
+	Ignore
+	// CollectAndReturn drops the result of a panicking task
+	// and continues processing.
+	// Once processing ends,
+	// ManageTasksWith returns the panics joined into a multierror of *PanicError
+	// instead of rethrowing them.
+	CollectAndReturn
+)
+
+// OnPanic sets the policy for handling panicking tasks.
+// A panic by the manager itself is always rethrown.
+func OnPanic(policy PanicPolicy) Option {
+	return func(o *options) {
+		o.panicPolicy = policy
+	}
+}
@@ -2,9 +2,17 @@ package flowmatic_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/carlmjohnson/flowmatic"
 )
@@ -17,6 +25,14 @@ func try(f func()) (r any) {
 	return
 }
 
+// taskPanic returns the input and value of a rethrown *PanicError.
+func taskPanic(r any) (input, value any) {
+	if pe, ok := r.(*flowmatic.PanicError); ok {
+		return pe.Input, pe.Value
+	}
+	return nil, r
+}
+
 func TestManageTasks_panic(t *testing.T) {
 	task := func(n int) (int, error) {
 		if n == 3 {
@@ -35,7 +51,10 @@ func TestManageTasks_panic(t *testing.T) {
 	if r == nil {
 		t.Fatal("should have panicked")
 	}
-	if r != "3!!" {
+	if in, v := taskPanic(r); in != 3 || v != "3!!" {
+		t.Fatal(r)
+	}
+	if !strings.Contains(fmt.Sprint(r), "input: 3") {
 		t.Fatal(r)
 	}
 	if fmt.Sprint(triples) != "[3 6]" {
@@ -199,3 +218,256 @@ func TestMap_panic(t *testing.T) {
 		t.Fatal(o)
 	}
 }
+
+func TestManageTasksWith_collectPanics(t *testing.T) {
+	var started sync.WaitGroup
+	started.Add(3)
+	task := func(_ context.Context, n int) (int, error) {
+		// make sure every task is running before any panics
+		started.Done()
+		started.Wait()
+		panic(n)
+	}
+	manager := func(int, int, error) ([]int, bool) {
+		return nil, true
+	}
+	r := try(func() {
+		_ = flowmatic.ManageTasksWith(context.Background(), 3, task, manager,
+			[]int{1, 2, 3}, flowmatic.CollectPanics())
+	})
+	mp, ok := r.(flowmatic.MultiPanic)
+	if !ok {
+		t.Fatal(r)
+	}
+	var vals []int
+	for _, pe := range mp {
+		if len(pe.Stack) == 0 {
+			t.Fatal("missing stack")
+		}
+		vals = append(vals, pe.Value.(int))
+	}
+	slices.Sort(vals)
+	if fmt.Sprint(vals) != "[1 2 3]" {
+		t.Fatal(vals)
+	}
+}
+
+func TestManageTasks_managerPanic(t *testing.T) {
+	before := runtime.NumGoroutine()
+	var running atomic.Int64
+	task := func(n int) (int, error) {
+		running.Add(1)
+		defer running.Add(-1)
+		time.Sleep(time.Duration(n) * time.Millisecond)
+		return n, nil
+	}
+	manager := func(in, out int, err error) ([]int, bool) {
+		if in == 1 {
+			panic("manager!!")
+		}
+		return nil, true
+	}
+	r := try(func() {
+		flowmatic.ManageTasks(4, task, manager, 1, 10, 10, 10)
+	})
+	if r != "manager!!" {
+		t.Fatal(r)
+	}
+	if n := running.Load(); n != 0 {
+		t.Fatal("tasks still running after panic:", n)
+	}
+	err := flowmatic.ManageTasksErr(4, task, manager, 1, 10, 10, 10)
+	var pe *flowmatic.PanicError
+	if !errors.As(err, &pe) || pe.Value != "manager!!" || len(pe.Stack) == 0 {
+		t.Fatal(err)
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Fatal(before, after)
+	}
+}
+
+func TestDoAll_panicStress(t *testing.T) {
+	before := runtime.NumGoroutine()
+	const n = 100
+	for range 20 {
+		var finished atomic.Int64
+		tasks := make([]func() error, n)
+		ctasks := make([]func(context.Context) error, n)
+		for i := range n {
+			tasks[i] = func() error {
+				defer finished.Add(1)
+				if i%3 == 1 {
+					panic(i)
+				}
+				return nil
+			}
+			ctasks[i] = func(context.Context) error {
+				return tasks[i]()
+			}
+		}
+		r := try(func() { _ = flowmatic.Do(tasks...) })
+		if r != 1 {
+			t.Fatal(r)
+		}
+		if f := finished.Load(); f != n {
+			t.Fatal("rethrown before all tasks finished:", f)
+		}
+		finished.Store(0)
+		r = try(func() { _ = flowmatic.All(context.Background(), ctasks...) })
+		if r != 1 {
+			t.Fatal(r)
+		}
+		if f := finished.Load(); f != n {
+			t.Fatal("rethrown before all tasks finished:", f)
+		}
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Fatal(before, after)
+	}
+}
+
+func TestManageTasksRecover(t *testing.T) {
+	task := func(n int) (int, error) {
+		if n%2 == 1 {
+			panic(n)
+		}
+		return n, nil
+	}
+	var panics, sum int
+	manager := func(r flowmatic.Result[int, int]) ([]int, bool) {
+		if r.Panic != nil {
+			if r.Panic != r.In || len(r.Stack) == 0 {
+				t.Fatal(r)
+			}
+			panics++
+			return nil, r.In != 7
+		}
+		sum += r.Out
+		return nil, true
+	}
+	flowmatic.ManageTasksRecover(1, task, manager, 1, 2, 3, 4, 5, 6, 7, 8)
+	if panics != 4 || sum != 12 {
+		t.Fatal(panics, sum)
+	}
+}
+
+func TestManageTasksWith_onPanic(t *testing.T) {
+	task := func(_ context.Context, n int) (int, error) {
+		if n%2 == 1 {
+			panic(n)
+		}
+		return n, nil
+	}
+	var calls, panics int
+	manager := func(in, out int, err error) ([]int, bool) {
+		calls++
+		var pe *flowmatic.PanicError
+		if errors.As(err, &pe) {
+			if pe.Value != in {
+				t.Fatal(pe.Value, in)
+			}
+			panics++
+		}
+		return nil, true
+	}
+	inputs := []int{1, 2, 3, 4, 5}
+	err := flowmatic.ManageTasksWith(context.Background(), 2, task, manager, inputs,
+		flowmatic.OnPanic(flowmatic.Ignore))
+	if err != nil || calls != 5 || panics != 3 {
+		t.Fatal(err, calls, panics)
+	}
+
+	calls, panics = 0, 0
+	err = flowmatic.ManageTasksWith(context.Background(), 2, task, manager, inputs,
+		flowmatic.OnPanic(flowmatic.CollectAndReturn))
+	if calls != 2 || panics != 0 {
+		t.Fatal(calls, panics)
+	}
+	var values []int
+	for _, err := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var pe *flowmatic.PanicError
+		if !errors.As(err, &pe) {
+			t.Fatal(err)
+		}
+		values = append(values, pe.Value.(int))
+	}
+	slices.Sort(values)
+	if s := fmt.Sprint(values); s != "[1 3 5]" {
+		t.Fatal(s)
+	}
+
+	r := try(func() {
+		_ = flowmatic.ManageTasksWith(context.Background(), 2, task, manager, inputs,
+			flowmatic.OnPanic(flowmatic.Rethrow))
+	})
+	if in, v := taskPanic(r); in != v || v != 1 && v != 3 && v != 5 {
+		t.Fatal(r)
+	}
+}
+
+func TestManageTasksWith_recoverPanicsFalse(t *testing.T) {
+	task := func(_ context.Context, n int) (int, error) {
+		if n == 2 {
+			panic("2!!")
+		}
+		return n, nil
+	}
+	manager := func(in, out int, err error) ([]int, bool) {
+		return nil, true
+	}
+	if os.Getenv("FLOWMATIC_CRASH") == "1" {
+		_ = flowmatic.ManageTasksWith(context.Background(), 2, task, manager,
+			[]int{1, 2, 3}, flowmatic.RecoverPanics(false), flowmatic.OnPanic(flowmatic.Ignore))
+		return
+	}
+	// the panic escapes the run without being recovered
+	r := try(func() {
+		_ = flowmatic.ManageTasksWith(context.Background(), 2, task, manager,
+			[]int{1, 2, 3}, flowmatic.RecoverPanics(false), flowmatic.OnPanic(flowmatic.Ignore), flowmatic.Synchronous())
+	})
+	if r != "2!!" {
+		t.Fatal(r)
+	}
+	// with workers, the panic crashes the program
+	cmd := exec.Command(os.Args[0], "-test.run=^TestManageTasksWith_recoverPanicsFalse$")
+	cmd.Env = append(os.Environ(), "FLOWMATIC_CRASH=1")
+	out, err := cmd.CombinedOutput()
+	if err == nil || !strings.Contains(string(out), "panic: 2!!") {
+		t.Fatal(err, string(out))
+	}
+}
+
+func TestManageTasksWith_stackCapture(t *testing.T) {
+	task := func(_ context.Context, n int) (int, error) {
+		if n == 2 {
+			panic("2!!")
+		}
+		return n, nil
+	}
+	manager := func(in, out int, err error) ([]int, bool) {
+		return nil, true
+	}
+	r := try(func() {
+		_ = flowmatic.ManageTasksWith(context.Background(), 2, task, manager, []int{1, 2, 3})
+	})
+	pe, ok := r.(*flowmatic.PanicError)
+	if !ok || !strings.Contains(pe.Error(), "input: 2") ||
+		!strings.Contains(pe.Error(), "panic_test.go") {
+		t.Fatal(r)
+	}
+	for _, sync := range []bool{false, true} {
+		opts := []flowmatic.Option{
+			flowmatic.StackCapture(func() []byte { return []byte("trimmed") }),
+		}
+		if sync {
+			opts = append(opts, flowmatic.Synchronous())
+		}
+		r = try(func() {
+			_ = flowmatic.ManageTasksWith(context.Background(), 2, task, manager, []int{1, 2, 3}, opts...)
+		})
+		pe, ok = r.(*flowmatic.PanicError)
+		if !ok || string(pe.Stack) != "trimmed" || pe.Error() != "panic: 2!! (input: 2)\n\ntrimmed" {
+			t.Fatal(sync, r)
+		}
+	}
+}
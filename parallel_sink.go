@@ -0,0 +1,58 @@
+package flowmatic
+
+// ParallelSink starts numWorkers concurrent workers (or GOMAXPROCS workers if numWorkers < 1)
+// and runs task once for each input.
+// Instead of a serial manager,
+// the results are given to numSinks concurrent sink Goroutines (or GOMAXPROCS if numSinks < 1),
+// so sink must be safe for concurrent use.
+// Because sinks cannot queue new inputs,
+// ParallelSink suits expensive post-processing such as writing each result to disk.
+// Results of panicking tasks are not given to sink.
+// If a task or sink panics,
+// processing continues,
+// and the first panic is rethrown in the parent Goroutine
+// once every input has been processed.
+func ParallelSink[Input, Output any](numWorkers, numSinks int, task Task[Input, Output], sink func(Result[Input, Output]), inputs ...Input) {
+	type void struct{}
+	in, out := TaskPool(numWorkers, task)
+	sinkIn, sinkOut := TaskPool(numSinks, func(r Result[Input, Output]) (void, error) {
+		sink(r)
+		return void{}, nil
+	})
+	var taskPanic, sinkPanic any
+	_ = Do(
+		func() error {
+			for _, input := range inputs {
+				in <- input
+			}
+			close(in)
+			return nil
+		},
+		func() error {
+			for r := range out {
+				if r.Panic != nil {
+					if taskPanic == nil {
+						taskPanic = r.Panic
+					}
+					continue
+				}
+				sinkIn <- r
+			}
+			close(sinkIn)
+			return nil
+		},
+		func() error {
+			for r := range sinkOut {
+				if r.Panic != nil && sinkPanic == nil {
+					sinkPanic = r.Panic
+				}
+			}
+			return nil
+		})
+	if taskPanic != nil {
+		panic(taskPanic)
+	}
+	if sinkPanic != nil {
+		panic(sinkPanic)
+	}
+}
@@ -0,0 +1,70 @@
+package flowmatic_test
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+func TestParallelSink(t *testing.T) {
+	task := func(n int) (int, error) {
+		return n * 2, nil
+	}
+	var (
+		sum             atomic.Int64
+		active, maxSeen atomic.Int64
+		mu              sync.Mutex
+		seen            = map[int]bool{}
+	)
+	sink := func(r flowmatic.Result[int, int]) {
+		n := active.Add(1)
+		defer active.Add(-1)
+		for {
+			m := maxSeen.Load()
+			if n <= m || maxSeen.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		sum.Add(int64(r.Out))
+		mu.Lock()
+		seen[r.In] = true
+		mu.Unlock()
+	}
+	inputs := make([]int, 40)
+	for i := range inputs {
+		inputs[i] = i
+	}
+	flowmatic.ParallelSink(2, 4, task, sink, inputs...)
+	if n := sum.Load(); n != 2*780 {
+		t.Fatal(n)
+	}
+	if len(seen) != 40 {
+		t.Fatal(len(seen))
+	}
+	if m := maxSeen.Load(); m < 2 || m > 4 {
+		t.Fatal("sinks should run concurrently:", m)
+	}
+}
+
+func TestParallelSink_panic(t *testing.T) {
+	task := func(n int) (int, error) {
+		if n == 3 {
+			panic("3!!")
+		}
+		return n, nil
+	}
+	var sunk atomic.Int64
+	sink := func(r flowmatic.Result[int, int]) {
+		sunk.Add(1)
+	}
+	r := try(func() {
+		flowmatic.ParallelSink(2, 2, task, sink, 1, 2, 3, 4, 5)
+	})
+	if r != "3!!" || sunk.Load() != 4 {
+		t.Fatal(r, sunk.Load())
+	}
+}
@@ -0,0 +1,65 @@
+package flowmatic
+
+import (
+	"sync"
+)
+
+// Pauser pauses and resumes the task management loop of ManageTasksWith.
+// While paused, no new tasks are started,
+// but tasks which are already running finish
+// and their results are given to the manager as usual.
+// The queue is kept, so resuming continues where the loop left off.
+// The zero value is ready to use and not paused.
+// A Pauser is safe for concurrent use.
+type Pauser struct {
+	mu     sync.Mutex
+	paused bool
+	// changed is closed when paused changes.
+	changed chan struct{}
+}
+
+// Pause stops new tasks from starting.
+// A task being dispatched at the moment Pause is called may still start.
+// Calling Pause while already paused has no effect.
+func (p *Pauser) Pause() { p.set(true) }
+
+// Resume lets new tasks start again.
+// Calling Resume while not paused has no effect.
+func (p *Pauser) Resume() { p.set(false) }
+
+// Paused reports whether p is paused.
+func (p *Pauser) Paused() bool {
+	paused, _ := p.state()
+	return paused
+}
+
+func (p *Pauser) set(paused bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.paused == paused {
+		return
+	}
+	p.paused = paused
+	if p.changed != nil {
+		close(p.changed)
+		p.changed = nil
+	}
+}
+
+// state returns whether p is paused
+// and a channel which is closed once that changes.
+func (p *Pauser) state() (paused bool, changed <-chan struct{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.changed == nil {
+		p.changed = make(chan struct{})
+	}
+	return p.paused, p.changed
+}
+
+// Pausable lets p pause and resume the task management loop.
+func Pausable(p *Pauser) Option {
+	return func(o *options) {
+		o.pauser = p
+	}
+}
@@ -0,0 +1,49 @@
+package flowmatic_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+func TestPausable(t *testing.T) {
+	var p flowmatic.Pauser
+	var started atomic.Int64
+	task := func(_ context.Context, n int) (int, error) {
+		started.Add(1)
+		return n, nil
+	}
+	calls := 0
+	manager := func(in, out int, err error) ([]int, bool) {
+		calls++
+		if in == 2 {
+			p.Pause()
+			p.Pause()
+		}
+		return nil, true
+	}
+	done := make(chan error)
+	go func() {
+		done <- flowmatic.ManageTasksWith(context.Background(), 1, task, manager,
+			[]int{1, 2, 3, 4}, flowmatic.Pausable(&p))
+	}()
+	time.Sleep(20 * time.Millisecond)
+	if !p.Paused() {
+		t.Fatal("should be paused")
+	}
+	// the task for 3 may have been dispatched before the pause
+	if n := started.Load(); n > 3 {
+		t.Fatal(n)
+	}
+	p.Resume()
+	p.Resume()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if calls != 4 || started.Load() != 4 {
+		t.Fatal(calls, started.Load())
+	}
+}
@@ -0,0 +1,155 @@
+package flowmatic
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// Pool is a pool of workers which execute a task,
+// like TaskPool,
+// but the workers are kept running between calls to Run,
+// and the number of workers can be changed while it is running.
+// Call Close to stop the workers once the Pool is no longer needed.
+type Pool[Input, Output any] struct {
+	task Task[Input, Output]
+	in   chan Input
+	out  chan Result[Input, Output]
+	quit chan struct{}
+	done chan struct{}
+	wg   sync.WaitGroup
+
+	running atomic.Bool
+
+	mu      sync.Mutex
+	workers int
+	closed  bool
+}
+
+// NewPool starts a Pool with numWorkers workers (or GOMAXPROCS workers if numWorkers < 1)
+// which execute task.
+func NewPool[Input, Output any](numWorkers int, task Task[Input, Output]) *Pool[Input, Output] {
+	if numWorkers < 1 {
+		numWorkers = runtime.GOMAXPROCS(0)
+	}
+	p := &Pool[Input, Output]{
+		task: task,
+		in:   make(chan Input),
+		out:  make(chan Result[Input, Output], numWorkers),
+		quit: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	p.SetWorkers(numWorkers)
+	return p
+}
+
+// SetWorkers changes the number of workers to n (or GOMAXPROCS if n < 1).
+// It is safe to call SetWorkers concurrently,
+// including from a manager while Run is in progress.
+// When scaling up, new workers start immediately.
+// When scaling down, excess workers finish their current task before exiting.
+// New workers are numbered from the current worker count,
+// so after scaling down and back up,
+// the WorkerID of a Result may be shared by two workers.
+func (p *Pool[Input, Output]) SetWorkers(n int) {
+	if n < 1 {
+		n = runtime.GOMAXPROCS(0)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return
+	}
+	for ; p.workers < n; p.workers++ {
+		p.wg.Add(1)
+		go p.work(p.workers)
+	}
+	for ; p.workers > n; p.workers-- {
+		// Signal asynchronously, so SetWorkers does not block
+		// waiting for a busy worker to finish its task.
+		go func() {
+			select {
+			case p.quit <- struct{}{}:
+			case <-p.done:
+			}
+		}()
+	}
+}
+
+// Workers returns the number of workers the Pool is scaled to.
+// Workers which are finishing their last task after scaling down are not counted.
+func (p *Pool[Input, Output]) Workers() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.workers
+}
+
+func (p *Pool[Input, Output]) work(id int) {
+	defer p.wg.Done()
+	for {
+		select {
+		case <-p.quit:
+			return
+		case in := <-p.in:
+			p.out <- runTask(id, p.task, in, &options{})
+		case <-p.done:
+			return
+		}
+	}
+}
+
+// Run manages tasks like ManageTasksContext using the workers of the Pool.
+// Run may be called any number of times, but not concurrently.
+// The workers are kept running after Run returns,
+// so later calls do not need to start new Goroutines.
+// State captured by manager carries over between calls which share it,
+// so build a new manager for each call,
+// or use RunResettable.
+func (p *Pool[Input, Output]) Run(ctx context.Context, manager Manager[Input, Output], initial ...Input) error {
+	if !p.running.CompareAndSwap(false, true) {
+		panic("flowmatic: Pool.Run called concurrently")
+	}
+	defer p.running.Store(false)
+	p.mu.Lock()
+	closed := p.closed
+	p.mu.Unlock()
+	if closed {
+		panic("flowmatic: Pool.Run called after Close")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	l := newLoop(&options{}, p.in, p.out, fromManager(manager), newFIFO(initial...), nopObserver[Input]{})
+	return rethrow(l.run(ctx, cancel))
+}
+
+// ResettableManager is a manager with state,
+// such as a set of inputs already seen,
+// which can be cleared between runs.
+type ResettableManager[Input, Output any] interface {
+	// Manage is called like a Manager.
+	Manage(Input, Output, error) ([]Input, bool)
+	// Reset clears the state of the manager.
+	Reset()
+}
+
+// RunResettable is like Run,
+// but it calls manager.Reset before starting,
+// so no state carries over from an earlier run with the same manager.
+func (p *Pool[Input, Output]) RunResettable(ctx context.Context, manager ResettableManager[Input, Output], initial ...Input) error {
+	manager.Reset()
+	return p.Run(ctx, manager.Manage, initial...)
+}
+
+// Close stops the workers of the Pool and waits for them to exit.
+// Close must not be called while Run is in progress.
+// Calling Close more than once has no effect.
+func (p *Pool[Input, Output]) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return
+	}
+	p.closed = true
+	close(p.done)
+	p.wg.Wait()
+}
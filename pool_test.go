@@ -0,0 +1,116 @@
+package flowmatic_test
+
+import (
+	"context"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+func TestPool_SetWorkers(t *testing.T) {
+	var running, maxRunning atomic.Int64
+	task := func(n int) (int, error) {
+		cur := running.Add(1)
+		defer running.Add(-1)
+		for {
+			old := maxRunning.Load()
+			if cur <= old || maxRunning.CompareAndSwap(old, cur) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		return n, nil
+	}
+	p := flowmatic.NewPool(1, task)
+	defer p.Close()
+	var peaks []int64
+	manager := func(in, out int, err error) ([]int, bool) {
+		switch in {
+		case 0:
+			p.SetWorkers(4)
+			return []int{1, 1, 1, 1, 1, 1, 1, 1}, true
+		case 1:
+			if p.Workers() == 4 {
+				peaks = append(peaks, maxRunning.Load())
+				p.SetWorkers(1)
+				maxRunning.Store(0)
+				return []int{2, 2, 2, 2}, true
+			}
+		}
+		return nil, true
+	}
+	if err := p.Run(context.Background(), manager, 0); err != nil {
+		t.Fatal(err)
+	}
+	if len(peaks) != 1 || peaks[0] < 2 {
+		t.Fatal(peaks)
+	}
+	if p.Workers() != 1 {
+		t.Fatal(p.Workers())
+	}
+}
+
+func TestPool_reuse(t *testing.T) {
+	before := runtime.NumGoroutine()
+	p := flowmatic.NewPool(4, func(n int) (int, error) {
+		return n * n, nil
+	})
+	for run := 0; run < 3; run++ {
+		sum := 0
+		manager := func(in, out int, err error) ([]int, bool) {
+			sum += out
+			return nil, true
+		}
+		if err := p.Run(context.Background(), manager, 1, 2, 3); err != nil {
+			t.Fatal(err)
+		}
+		if sum != 14 {
+			t.Fatal(run, sum)
+		}
+	}
+	p.Close()
+	p.Close()
+	if after := runtime.NumGoroutine(); after > before {
+		t.Fatal(before, after)
+	}
+	if r := try(func() { _ = p.Run(context.Background(), nil) }); r == nil {
+		t.Fatal("should have panicked")
+	}
+}
+
+// crawlManager expands each input to its double below 100,
+// skipping inputs it has already seen.
+type crawlManager struct {
+	seen map[int]bool
+}
+
+func (m *crawlManager) Reset() {
+	m.seen = make(map[int]bool)
+}
+
+func (m *crawlManager) Manage(in, out int, err error) ([]int, bool) {
+	m.seen[in] = true
+	if next := 2 * in; next < 100 && !m.seen[next] {
+		return []int{next}, true
+	}
+	return nil, true
+}
+
+func TestPool_RunResettable(t *testing.T) {
+	p := flowmatic.NewPool(2, func(n int) (int, error) {
+		return n, nil
+	})
+	defer p.Close()
+	var m crawlManager
+	for run := 0; run < 3; run++ {
+		if err := p.RunResettable(context.Background(), &m, 3); err != nil {
+			t.Fatal(err)
+		}
+		if len(m.seen) != 6 {
+			t.Fatal(run, m.seen)
+		}
+	}
+}
@@ -0,0 +1,363 @@
+package flowmatic
+
+import (
+	"math/rand/v2"
+	"time"
+
+	"github.com/carlmjohnson/deque"
+)
+
+// queue holds inputs waiting to be dispatched to a worker.
+type queue[T any] interface {
+	// push adds items to the queue.
+	push(items ...T)
+	// peek returns the next item to dispatch without removing it.
+	peek() (T, bool)
+	// pop removes the item returned by peek.
+	pop()
+	len() int
+}
+
+// waiter is implemented by a queue which may hold back items
+// which are not ready to dispatch yet.
+// The loop waits on the channel returned by wait
+// before peeking at the queue again.
+type waiter interface {
+	wait() <-chan time.Time
+}
+
+// blocker is implemented by a queue which may hold back items
+// until the manager has been given more results.
+// While blocked reports true, dispatching cannot make room in the queue,
+// so the loop gives held results to the manager even if the queue is full.
+type blocker interface {
+	blocked() bool
+}
+
+// failer is implemented by a queue which can fail,
+// such as by losing items.
+// The loop halts with the error returned by failed once it is non-nil.
+type failer interface {
+	failed() error
+}
+
+// tunable is implemented by a queue whose capacity
+// can be set with the InitialQueueCap and ShrinkQueue options.
+type tunable interface {
+	tune(initialCap int, shrink bool)
+}
+
+// fifo is a first-in, first-out queue.
+// Even for small batches,
+// the deque accounts for only a few percent of the time
+// and a handful of the allocations of a run
+// (see BenchmarkManageTasks_smallBatch);
+// the channel operations between the loop and the workers dominate,
+// so there is no separate fast path for small queues.
+type fifo[T any] struct {
+	d *deque.Deque[T]
+	// shrink is set by the ShrinkQueue option,
+	// and minCap by the InitialQueueCap option.
+	shrink bool
+	minCap int
+}
+
+func newFIFO[T any](items ...T) *fifo[T] {
+	return &fifo[T]{d: deque.Of(items...)}
+}
+
+func (q *fifo[T]) push(items ...T) { q.d.PushBackSlice(items) }
+func (q *fifo[T]) peek() (T, bool) { return q.d.Head() }
+func (q *fifo[T]) len() int        { return q.d.Len() }
+
+func (q *fifo[T]) pop() {
+	q.d.RemoveFront()
+	if q.shrink {
+		q.compact()
+	}
+}
+
+func (q *fifo[T]) tune(initialCap int, shrink bool) {
+	q.minCap = initialCap
+	q.shrink = shrink
+	if n := initialCap - q.d.Len(); n > 0 {
+		q.d.Grow(n)
+	}
+}
+
+// shuffle puts the items in the queue in a random order chosen by seed.
+func (q *fifo[T]) shuffle(seed int64) {
+	rng := rand.New(rand.NewPCG(uint64(seed), 0))
+	for i := q.d.Len() - 1; i > 0; i-- {
+		q.d.Swap(i, rng.IntN(i+1))
+	}
+}
+
+// minShrinkCap is the capacity below which ShrinkQueue leaves the queue alone.
+const minShrinkCap = 64
+
+// compact copies the queue into a smaller backing array
+// once it has drained to a quarter of its capacity,
+// keeping at least the initial capacity.
+// Because the queue must drain by three quarters between copies
+// and a copy moves only the items left,
+// the cost is amortized over the pops.
+func (q *fifo[T]) compact() {
+	c := q.d.Cap()
+	if c <= max(q.minCap, minShrinkCap) || q.d.Len() > c/4 {
+		return
+	}
+	q.d.Clip()
+	if n := q.minCap - q.d.Len(); n > 0 {
+		q.d.Grow(n)
+	}
+}
+
+// lifo is a last-in, first-out queue.
+type lifo[T any] struct {
+	items []T
+}
+
+func (q *lifo[T]) push(items ...T) { q.items = append(q.items, items...) }
+
+func (q *lifo[T]) peek() (t T, ok bool) {
+	if len(q.items) == 0 {
+		return t, false
+	}
+	return q.items[len(q.items)-1], true
+}
+
+func (q *lifo[T]) pop() {
+	n := len(q.items) - 1
+	if n < 0 {
+		return
+	}
+	var zero T
+	q.items[n] = zero
+	q.items = q.items[:n]
+}
+
+func (q *lifo[T]) len() int { return len(q.items) }
+
+// pull is a first-in, first-out queue
+// which lazily pulls another item from next
+// when it is peeked at while empty.
+// Its len only counts the items already pulled,
+// so checking whether it is empty never consumes an item.
+type pull[T any] struct {
+	*fifo[T]
+	next func() (T, bool)
+}
+
+func newPull[T any](next func() (T, bool)) *pull[T] {
+	return &pull[T]{newFIFO[T](), next}
+}
+
+func (q *pull[T]) fill() {
+	if q.next == nil || q.fifo.len() > 0 {
+		return
+	}
+	if item, ok := q.next(); ok {
+		q.fifo.push(item)
+	} else {
+		q.next = nil
+	}
+}
+
+func (q *pull[T]) peek() (T, bool) { q.fill(); return q.fifo.peek() }
+func (q *pull[T]) more() bool      { return q.next != nil }
+
+// lazy is implemented by queues which may hold more items than len reports,
+// such as items not yet pulled from an iterator.
+type lazy interface {
+	// more reports whether peek may find items which are not counted by len.
+	more() bool
+}
+
+// priority is a binary heap which dispatches the least item first.
+type priority[T any] struct {
+	items []T
+	less  func(a, b T) bool
+}
+
+func newPriority[T any](less func(a, b T) bool, items ...T) *priority[T] {
+	q := &priority[T]{less: less}
+	q.push(items...)
+	return q
+}
+
+func (q *priority[T]) push(items ...T) {
+	for _, item := range items {
+		q.items = append(q.items, item)
+		q.up(len(q.items) - 1)
+	}
+}
+
+func (q *priority[T]) peek() (t T, ok bool) {
+	if len(q.items) == 0 {
+		return t, false
+	}
+	return q.items[0], true
+}
+
+func (q *priority[T]) pop() {
+	n := len(q.items) - 1
+	if n < 0 {
+		return
+	}
+	q.items[0] = q.items[n]
+	var zero T
+	q.items[n] = zero
+	q.items = q.items[:n]
+	q.down(0)
+}
+
+func (q *priority[T]) len() int { return len(q.items) }
+
+func (q *priority[T]) up(i int) {
+	for i > 0 {
+		parent := (i - 1) / 2
+		if !q.less(q.items[i], q.items[parent]) {
+			return
+		}
+		q.items[i], q.items[parent] = q.items[parent], q.items[i]
+		i = parent
+	}
+}
+
+func (q *priority[T]) down(i int) {
+	n := len(q.items)
+	for {
+		least := i
+		if l := 2*i + 1; l < n && q.less(q.items[l], q.items[least]) {
+			least = l
+		}
+		if r := 2*i + 2; r < n && q.less(q.items[r], q.items[least]) {
+			least = r
+		}
+		if least == i {
+			return
+		}
+		q.items[i], q.items[least] = q.items[least], q.items[i]
+		i = least
+	}
+}
+
+// dedup wraps a queue and drops any item whose key has been pushed before.
+type dedup[T any, K comparable] struct {
+	queue[T]
+	keyOf func(T) K
+	seen  map[K]struct{}
+}
+
+func newDedup[T any, K comparable](q queue[T], keyOf func(T) K, items ...T) *dedup[T, K] {
+	d := &dedup[T, K]{q, keyOf, make(map[K]struct{})}
+	d.push(items...)
+	return d
+}
+
+func (q *dedup[T, K]) push(items ...T) {
+	for _, item := range items {
+		key := q.keyOf(item)
+		if _, ok := q.seen[key]; ok {
+			continue
+		}
+		q.seen[key] = struct{}{}
+		q.queue.push(item)
+	}
+}
+
+// batches is a first-in, first-out queue of items
+// which are dispatched in batches of up to size items.
+// A batch is never held back waiting to be filled,
+// so the last items queued are dispatched in a partial batch.
+type batches[T any] struct {
+	items *deque.Deque[T]
+	size  int
+	// head caches the batch returned by peek.
+	head []T
+}
+
+func newBatches[T any](size int, items ...T) *batches[T] {
+	return &batches[T]{items: deque.Of(items...), size: max(size, 1)}
+}
+
+func (q *batches[T]) push(batches ...[]T) {
+	for _, items := range batches {
+		q.items.PushBackSlice(items)
+	}
+	if len(q.head) < q.size {
+		q.head = nil
+	}
+}
+
+func (q *batches[T]) peek() ([]T, bool) {
+	if q.head == nil {
+		n := min(q.size, q.items.Len())
+		if n == 0 {
+			return nil, false
+		}
+		q.head = make([]T, n)
+		for i := range q.head {
+			q.head[i], _ = q.items.At(i)
+		}
+	}
+	return q.head, true
+}
+
+func (q *batches[T]) pop() {
+	if head, ok := q.peek(); ok {
+		for range head {
+			q.items.RemoveFront()
+		}
+	}
+	q.head = nil
+}
+
+func (q *batches[T]) len() int {
+	return (q.items.Len() + q.size - 1) / q.size
+}
+
+// shuffle is a queue which dispatches its items in random order.
+// Removing an item swaps the last item into its place,
+// so adding and dispatching items are O(1).
+type shuffle[T any] struct {
+	items []T
+	rng   *rand.Rand
+	// next is the index of the item returned by peek, or -1.
+	next int
+}
+
+func newShuffle[T any](seed int64, items ...T) *shuffle[T] {
+	return &shuffle[T]{
+		items: items,
+		rng:   rand.New(rand.NewPCG(uint64(seed), 0)),
+		next:  -1,
+	}
+}
+
+func (q *shuffle[T]) push(items ...T) { q.items = append(q.items, items...) }
+
+func (q *shuffle[T]) peek() (t T, ok bool) {
+	if len(q.items) == 0 {
+		return t, false
+	}
+	if q.next < 0 {
+		q.next = q.rng.IntN(len(q.items))
+	}
+	return q.items[q.next], true
+}
+
+func (q *shuffle[T]) pop() {
+	if _, ok := q.peek(); !ok {
+		return
+	}
+	last := len(q.items) - 1
+	q.items[q.next] = q.items[last]
+	var zero T
+	q.items[last] = zero
+	q.items = q.items[:last]
+	q.next = -1
+}
+
+func (q *shuffle[T]) len() int { return len(q.items) }
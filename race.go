@@ -3,6 +3,7 @@ package flowmatic
 import (
 	"context"
 	"errors"
+	"sync"
 	"sync/atomic"
 )
 
@@ -43,3 +44,60 @@ func Race(ctx context.Context, tasks ...func(context.Context) error) error {
 	}
 	return errors.Join(errs...)
 }
+
+// RaceValue is like Race,
+// but each task returns a value,
+// and RaceValue returns the value of the first task to succeed.
+// The other tasks are canceled as soon as the first task succeeds.
+// If all tasks return an error,
+// RaceValue returns the zero value and a multierror containing all the errors.
+// If a task panics during execution,
+// a panic will be caught and rethrown in the parent Goroutine.
+func RaceValue[T any](ctx context.Context, tasks ...func(context.Context) (T, error)) (T, error) {
+	val, _, err := RaceIndex(ctx, tasks...)
+	return val, err
+}
+
+// RaceIndex is like RaceValue,
+// but it also returns the index of the first task to succeed,
+// or -1 if all tasks return an error.
+// The context of every other task is canceled as soon as the first task succeeds,
+// but RaceIndex still waits for them to return,
+// so it is the caller's responsibility to ensure that tasks honor cancelation.
+func RaceIndex[T any](ctx context.Context, tasks ...func(context.Context) (T, error)) (T, int, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	errs := make([]error, len(tasks))
+	var (
+		mu     sync.Mutex
+		winner = -1
+		val    T
+	)
+	_ = eachN(len(tasks), len(tasks), func(pos int) error {
+		defer func() {
+			panicVal := recover()
+			if panicVal != nil {
+				cancel()
+				panic(panicVal)
+			}
+		}()
+		v, err := tasks[pos](ctx)
+		if err != nil {
+			errs[pos] = err
+			return nil
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if winner == -1 {
+			winner = pos
+			val = v
+			cancel()
+		}
+		return nil
+	})
+	if winner != -1 {
+		return val, winner, nil
+	}
+	var zero T
+	return zero, -1, errors.Join(errs...)
+}
@@ -3,6 +3,7 @@ package flowmatic_test
 import (
 	"context"
 	"errors"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -47,3 +48,67 @@ func TestRace_join_errs(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestRaceValue(t *testing.T) {
+	start := time.Now()
+	val, err := flowmatic.RaceValue(context.Background(),
+		func(ctx context.Context) (string, error) {
+			if !sleepFor(ctx, time.Second) {
+				return "", ctx.Err()
+			}
+			return "slow", nil
+		},
+		func(ctx context.Context) (string, error) {
+			return "", errors.New("failed")
+		},
+		func(ctx context.Context) (string, error) {
+			sleepFor(ctx, time.Millisecond)
+			return "fast", nil
+		},
+	)
+	if val != "fast" || err != nil {
+		t.Fatal(val, err)
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Fatal("loser was not canceled")
+	}
+
+	a := errors.New("a")
+	b := errors.New("b")
+	val, err = flowmatic.RaceValue(context.Background(),
+		func(ctx context.Context) (string, error) { return "x", a },
+		func(ctx context.Context) (string, error) { return "y", b },
+	)
+	if val != "" || !errors.Is(err, a) || !errors.Is(err, b) {
+		t.Fatal(val, err)
+	}
+}
+
+func TestRaceIndex(t *testing.T) {
+	var canceled atomic.Bool
+	val, i, err := flowmatic.RaceIndex(context.Background(),
+		func(ctx context.Context) (string, error) {
+			if !sleepFor(ctx, time.Second) {
+				canceled.Store(true)
+				return "", ctx.Err()
+			}
+			return "slow", nil
+		},
+		func(ctx context.Context) (string, error) {
+			sleepFor(ctx, time.Millisecond)
+			return "fast", nil
+		},
+	)
+	if val != "fast" || i != 1 || err != nil {
+		t.Fatal(val, i, err)
+	}
+	if !canceled.Load() {
+		t.Fatal("loser was not canceled")
+	}
+	_, i, err = flowmatic.RaceIndex(context.Background(),
+		func(ctx context.Context) (string, error) { return "x", errors.New("x") },
+	)
+	if i != -1 || err == nil {
+		t.Fatal(i, err)
+	}
+}
@@ -0,0 +1,61 @@
+package flowmatic
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/time/rate"
+)
+
+// RateLimit wraps task so that it waits for limiter before each run.
+// A single limiter can safely be shared by every worker,
+// so tasks run no faster than the limiter allows
+// regardless of the number of workers.
+// If ctx is canceled while waiting,
+// the task is not run and the wait error is returned instead.
+// Pass the same ctx to ManageTasksContext to interrupt pending waits
+// when the task management loop is canceled.
+// With the WithClock option,
+// limiter is reserved and waited for by the time of that Clock,
+// so it must not also be used with the system clock.
+func RateLimit[Input, Output any](ctx context.Context, limiter *rate.Limiter, task Task[Input, Output], opts ...Option) Task[Input, Output] {
+	o := buildOptions(opts)
+	wait := limiter.Wait
+	if o.clk != nil {
+		wait = func(ctx context.Context) error {
+			return waitClock(ctx, o.clk, limiter)
+		}
+	}
+	return func(in Input) (out Output, err error) {
+		if err = wait(ctx); err != nil {
+			return out, err
+		}
+		return task(in)
+	}
+}
+
+// waitClock is like limiter.Wait, but it goes by the time of c.
+func waitClock(ctx context.Context, c Clock, limiter *rate.Limiter) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	now := c.Now()
+	r := limiter.ReserveN(now, 1)
+	if !r.OK() {
+		return fmt.Errorf("flowmatic: rate limit wait exceeds limiter's burst %d", limiter.Burst())
+	}
+	delay := r.DelayFrom(now)
+	if delay <= 0 {
+		return nil
+	}
+	t := c.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C():
+		return nil
+	case <-ctx.Done():
+		// give back the tokens for a later run
+		r.CancelAt(c.Now())
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,43 @@
+package flowmatic_test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/carlmjohnson/flowmatic"
+	"golang.org/x/time/rate"
+)
+
+func ExampleRateLimit() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Allow one request every 10ms, shared by all the workers
+	limiter := rate.NewLimiter(rate.Every(10*time.Millisecond), 1)
+	fetch := flowmatic.RateLimit(ctx, limiter, func(page int) (int, error) {
+		return page, nil
+	})
+
+	fetched := 0
+	manager := func(page, _ int, err error) ([]int, bool) {
+		if err != nil {
+			return nil, true
+		}
+		fetched++
+		if fetched == 3 {
+			// Canceling ctx also interrupts any workers waiting on the limiter
+			cancel()
+		}
+		return []int{page + 1}, true
+	}
+	start := time.Now()
+	err := flowmatic.ManageTasksContext(ctx, 10, fetch, manager, 1)
+	fmt.Println("err:", err)
+	fmt.Println("pages fetched:", fetched)
+	fmt.Println("rate limited?", time.Since(start) >= 20*time.Millisecond)
+	// Output:
+	// err: context canceled
+	// pages fetched: 3
+	// rate limited? true
+}
@@ -0,0 +1,23 @@
+package flowmatic
+
+import (
+	"runtime/debug"
+)
+
+// Recovering wraps task so that a panic is returned as a *PanicError
+// recording the input and stack of the task,
+// instead of being rethrown by the task management loop.
+// It opts a single risky task out of rethrowing
+// without changing the panic policy of the whole run.
+func Recovering[Input, Output any](task Task[Input, Output]) Task[Input, Output] {
+	return func(in Input) (out Output, err error) {
+		defer func() {
+			if pval := recover(); pval != nil {
+				var zero Output
+				out = zero
+				err = &PanicError{Input: in, Value: pval, Stack: debug.Stack()}
+			}
+		}()
+		return task(in)
+	}
+}
@@ -0,0 +1,33 @@
+package flowmatic_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+func TestRecovering(t *testing.T) {
+	task := flowmatic.Recovering(func(n int) (int, error) {
+		if n == 2 {
+			panic("two")
+		}
+		return n, nil
+	})
+	var sum, panics int
+	manager := func(in, out int, err error) ([]int, bool) {
+		var pe *flowmatic.PanicError
+		if errors.As(err, &pe) {
+			if pe.Input != 2 || pe.Value != "two" || len(pe.Stack) == 0 {
+				t.Fatal(pe)
+			}
+			panics++
+		}
+		sum += out
+		return nil, true
+	}
+	flowmatic.ManageTasks(2, task, manager, 1, 2, 3)
+	if sum != 4 || panics != 1 {
+		t.Fatal(sum, panics)
+	}
+}
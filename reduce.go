@@ -0,0 +1,24 @@
+package flowmatic
+
+import (
+	"context"
+)
+
+// Reduce starts numWorkers concurrent workers (or GOMAXPROCS workers if numWorkers < 1),
+// runs task once for each input,
+// and folds the results into an accumulator starting from initial.
+// Reduce calls reduce serially in completion order,
+// so it needs no locking.
+// Errors do not halt processing;
+// reduce receives each error and decides how to account for it.
+// If a task panics during execution,
+// the panic will be caught and rethrown in the parent Goroutine.
+func Reduce[Input, Output, Acc any](numWorkers int, task Task[Input, Output], reduce func(Acc, Output, error) Acc, initial Acc, inputs ...Input) Acc {
+	acc := initial
+	manager := func(_ Input, out Output, err error) ([]Input, bool) {
+		acc = reduce(acc, out, err)
+		return nil, true
+	}
+	_ = rethrow(manage(context.Background(), numWorkers, ignoreContext(task), manager, newFIFO(inputs...)))
+	return acc
+}
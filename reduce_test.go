@@ -0,0 +1,40 @@
+package flowmatic_test
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+func TestReduce(t *testing.T) {
+	type tally struct{ sum, errs int }
+	sum := func(acc tally, n int, err error) tally {
+		if err != nil {
+			acc.errs++
+			return acc
+		}
+		acc.sum += n
+		return acc
+	}
+	got := flowmatic.Reduce(3, strconv.Atoi, sum, tally{}, "1", "2", "x", "3", "4", "y")
+	if got != (tally{10, 2}) {
+		t.Fatal(got)
+	}
+	// reduce can build a single map without locking
+	lengths := flowmatic.Reduce(2, func(s string) (int, error) {
+		if s == "" {
+			return 0, errors.New("empty")
+		}
+		return len(s), nil
+	}, func(m map[int]int, n int, err error) map[int]int {
+		if err == nil {
+			m[n]++
+		}
+		return m
+	}, map[int]int{}, "a", "bb", "cc", "", "ddd")
+	if len(lengths) != 3 || lengths[2] != 2 {
+		t.Fatal(lengths)
+	}
+}
@@ -0,0 +1,43 @@
+package flowmatic
+
+import (
+	"context"
+)
+
+// Results starts numWorkers concurrent workers (or GOMAXPROCS workers if numWorkers < 1),
+// runs task once for each input,
+// and returns every Result in input order,
+// including the results of tasks which return ErrSkip.
+// Errors do not halt processing.
+// A panicking task does not halt processing either;
+// its Result has the recovered value in Panic,
+// the stack trace in Stack,
+// and a *PanicError as its Err.
+func Results[Input, Output any](numWorkers int, task Task[Input, Output], inputs ...Input) []Result[Input, Output] {
+	items := make([]sequenced[Input], len(inputs))
+	for i, in := range inputs {
+		items[i] = sequenced[Input]{i, in}
+	}
+	results := make([]Result[Input, Output], len(inputs))
+	m := func(r Result[sequenced[Input], Output]) ([]sequenced[Input], Action) {
+		res := Result[Input, Output]{
+			In:       r.In.val,
+			Out:      r.Out,
+			Err:      r.Err,
+			Panic:    r.Panic,
+			Stack:    r.Stack,
+			Duration: r.Duration,
+			WorkerID: r.WorkerID,
+		}
+		if r.Panic != nil {
+			res.Err = &PanicError{Input: r.In.val, Value: r.Panic, Stack: r.Stack}
+		}
+		results[r.In.seq] = res
+		return nil, Continue
+	}
+	t := func(_ context.Context, in sequenced[Input]) (Output, error) {
+		return task(in.val)
+	}
+	_ = rethrow(manageFunc(context.Background(), numWorkers, t, m, newFIFO(items...), deliverPanics(), keepSkips()))
+	return results
+}
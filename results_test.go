@@ -0,0 +1,40 @@
+package flowmatic_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+func TestResults(t *testing.T) {
+	bad := errors.New("bad")
+	task := func(n int) (int, error) {
+		switch n {
+		case 2:
+			return 0, bad
+		case 3:
+			panic("3!!")
+		}
+		return n * 10, nil
+	}
+	results := flowmatic.Results(2, task, 1, 2, 3, 4)
+	if len(results) != 4 {
+		t.Fatal(results)
+	}
+	for i, r := range results {
+		if r.In != i+1 {
+			t.Fatal(i, r)
+		}
+	}
+	if results[0].Out != 10 || results[0].Err != nil || results[3].Out != 40 {
+		t.Fatal(results)
+	}
+	if results[1].Err != bad {
+		t.Fatal(results[1])
+	}
+	var pe *flowmatic.PanicError
+	if !errors.As(results[2].Err, &pe) || pe.Value != "3!!" || results[2].Panic != "3!!" {
+		t.Fatal(results[2])
+	}
+}
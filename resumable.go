@@ -0,0 +1,55 @@
+package flowmatic
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+)
+
+// ManageTasksResumable is like ManageTasks,
+// but if the manager halts processing,
+// it returns the inputs which were never given to the manager.
+// That is every input still in the queue,
+// every input whose task was running when processing halted,
+// and any inputs the manager returned as it halted,
+// in the order they were queued.
+// Passing the remaining inputs as the initial inputs of a new run
+// resumes processing where it left off.
+// If the queue is exhausted, ManageTasksResumable returns nil.
+func ManageTasksResumable[Input, Output any](numWorkers int, task Task[Input, Output], manager Manager[Input, Output], initial ...Input) (remaining []Input) {
+	remaining, _ = manageResumable(context.Background(), numWorkers, ignoreContext(task), manager, initial)
+	return remaining
+}
+
+// manageResumable runs the loop like ManageTasksResumable,
+// returning the remaining inputs and the error from the loop.
+func manageResumable[Input, Output any](ctx context.Context, numWorkers int, task CTask[Input, Output], manager Manager[Input, Output], initial []Input, opts ...Option) (remaining []Input, err error) {
+	seq := 0
+	pending := make(map[int]Input)
+	wrap := func(items []Input) []sequenced[Input] {
+		wrapped := make([]sequenced[Input], len(items))
+		for i, item := range items {
+			wrapped[i] = sequenced[Input]{seq, item}
+			pending[seq] = item
+			seq++
+		}
+		return wrapped
+	}
+	m := func(in sequenced[Input], out Output, err error) ([]sequenced[Input], bool) {
+		delete(pending, in.seq)
+		if errors.Is(err, ErrSkip) {
+			return nil, true
+		}
+		items, ok := manager(in.val, out, err)
+		return wrap(items), ok
+	}
+	t := func(ctx context.Context, in sequenced[Input]) (Output, error) {
+		return task(ctx, in.val)
+	}
+	err = rethrow(manage(ctx, numWorkers, t, m, newFIFO(wrap(initial)...), append(opts[:len(opts):len(opts)], keepSkips())...))
+	for _, n := range slices.Sorted(maps.Keys(pending)) {
+		remaining = append(remaining, pending[n])
+	}
+	return remaining, err
+}
@@ -0,0 +1,43 @@
+package flowmatic_test
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+func TestManageTasksResumable(t *testing.T) {
+	task := func(n int) (int, error) {
+		return n, nil
+	}
+	var seen []int
+	stopAt := 5
+	manager := func(in, out int, err error) ([]int, bool) {
+		seen = append(seen, in)
+		if in < 10 {
+			return []int{in + 10}, in != stopAt
+		}
+		return nil, true
+	}
+	remaining := flowmatic.ManageTasksResumable(1, task, manager, 0, 1, 2, 3, 4, 5, 6, 7, 8, 9)
+	// the inputs returned by the manager as it halts are kept
+	if s := fmt.Sprint(remaining); s != "[6 7 8 9 10 11 12 13 14 15]" {
+		t.Fatal(s)
+	}
+	stopAt = -1
+	remaining = flowmatic.ManageTasksResumable(3, task, manager, remaining...)
+	if remaining != nil {
+		t.Fatal(remaining)
+	}
+	slices.Sort(seen)
+	for i := range 20 {
+		if _, found := slices.BinarySearch(seen, i); !found {
+			t.Fatal("missing", i, seen)
+		}
+	}
+	if len(seen) != 20 {
+		t.Fatal(seen)
+	}
+}
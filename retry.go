@@ -0,0 +1,81 @@
+package flowmatic
+
+import (
+	"context"
+	"time"
+)
+
+// Retry wraps task so that it is run up to attempts times until it succeeds.
+// After a failed attempt, Retry sleeps for backoff(attempt) before trying again,
+// where attempt counts from 1.
+// A nil backoff retries immediately.
+// If retryable is not nil and reports that an error cannot be retried,
+// Retry gives up without trying again.
+// Once Retry gives up, it returns the output and error of the last attempt.
+//
+// To limit the duration of each attempt, wrap task with WithTimeout
+// before passing it to Retry.
+// With the WithClock option, Retry sleeps between attempts on that Clock.
+func Retry[Input, Output any](attempts int, backoff func(attempt int) time.Duration, retryable func(error) bool, task Task[Input, Output], opts ...Option) Task[Input, Output] {
+	o := buildOptions(opts)
+	c := o.clock()
+	return func(in Input) (out Output, err error) {
+		for attempt := 1; ; attempt++ {
+			out, err = task(in)
+			if err == nil || attempt >= attempts {
+				return out, err
+			}
+			if retryable != nil && !retryable(err) {
+				return out, err
+			}
+			if backoff != nil {
+				<-c.After(backoff(attempt))
+			}
+		}
+	}
+}
+
+// RetryContext is like Retry,
+// but for a context-aware task.
+// The context given to each attempt records its attempt number,
+// counting from 1, which the task can read with AttemptFromContext,
+// such as to allow later attempts more time.
+// RetryContext stops waiting to retry once ctx is canceled
+// and returns the output and error of the last attempt.
+// Options apply as with Retry.
+func RetryContext[Input, Output any](attempts int, backoff func(attempt int) time.Duration, retryable func(error) bool, task CTask[Input, Output], opts ...Option) CTask[Input, Output] {
+	o := buildOptions(opts)
+	c := o.clock()
+	return func(ctx context.Context, in Input) (out Output, err error) {
+		for attempt := 1; ; attempt++ {
+			out, err = task(context.WithValue(ctx, attemptKey{}, attempt), in)
+			if err == nil || attempt >= attempts {
+				return out, err
+			}
+			if retryable != nil && !retryable(err) {
+				return out, err
+			}
+			if backoff != nil {
+				t := c.NewTimer(backoff(attempt))
+				select {
+				case <-ctx.Done():
+					t.Stop()
+					return out, err
+				case <-t.C():
+				}
+			} else if ctx.Err() != nil {
+				return out, err
+			}
+		}
+	}
+}
+
+type attemptKey struct{}
+
+// AttemptFromContext returns the attempt number recorded in ctx by RetryContext,
+// counting from 1,
+// or 0 if ctx does not come from RetryContext.
+func AttemptFromContext(ctx context.Context) int {
+	attempt, _ := ctx.Value(attemptKey{}).(int)
+	return attempt
+}
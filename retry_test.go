@@ -0,0 +1,84 @@
+package flowmatic_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+func TestRetry(t *testing.T) {
+	var (
+		calls     int
+		transient = errors.New("transient")
+		fatal     = errors.New("fatal")
+	)
+	errs := []error{transient, transient, nil}
+	task := func(string) (int, error) {
+		err := errs[calls]
+		calls++
+		return calls, err
+	}
+	var waits []time.Duration
+	backoff := func(attempt int) time.Duration {
+		d := time.Duration(attempt) * time.Microsecond
+		waits = append(waits, d)
+		return d
+	}
+	out, err := flowmatic.Retry(5, backoff, nil, task)("x")
+	if out != 3 || err != nil || calls != 3 || len(waits) != 2 {
+		t.Fatal(out, err, calls, waits)
+	}
+
+	calls = 0
+	errs = []error{transient, transient, transient}
+	out, err = flowmatic.Retry(2, nil, nil, task)("x")
+	if out != 2 || err != transient || calls != 2 {
+		t.Fatal(out, err, calls)
+	}
+
+	calls = 0
+	errs = []error{fatal, nil}
+	retryable := func(err error) bool { return err != fatal }
+	out, err = flowmatic.Retry(5, nil, retryable, task)("x")
+	if out != 1 || err != fatal || calls != 1 {
+		t.Fatal(out, err, calls)
+	}
+}
+
+func TestRetryContext(t *testing.T) {
+	var attempts []int
+	task := flowmatic.RetryContext(5, nil, nil, func(ctx context.Context, n int) (int, error) {
+		attempt := flowmatic.AttemptFromContext(ctx)
+		attempts = append(attempts, attempt)
+		if attempt < 3 {
+			return 0, errors.New("not yet")
+		}
+		return n, nil
+	})
+	if out, err := task(context.Background(), 7); out != 7 || err != nil {
+		t.Fatal(out, err)
+	}
+	if fmt.Sprint(attempts) != "[1 2 3]" {
+		t.Fatal(attempts)
+	}
+	if n := flowmatic.AttemptFromContext(context.Background()); n != 0 {
+		t.Fatal(n)
+	}
+
+	// cancelation stops the backoff
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	task = flowmatic.RetryContext(5, func(int) time.Duration { return time.Hour }, nil,
+		func(ctx context.Context, n int) (int, error) {
+			calls++
+			cancel()
+			return 0, errors.New("fail")
+		})
+	if _, err := task(ctx, 1); err == nil || calls != 1 {
+		t.Fatal(err, calls)
+	}
+}
@@ -0,0 +1,84 @@
+package flowmatic
+
+import (
+	"sync"
+)
+
+// WeightedTask is a task with a weight for RoundRobin.
+type WeightedTask[Input, Output any] struct {
+	// Weight is the share of inputs given to Task.
+	// A Weight less than 1 is treated as 1.
+	Weight int
+	Task   Task[Input, Output]
+}
+
+// RoundRobin returns a task which spreads its inputs over tasks by weight,
+// such as to share load between API keys with different rate limits.
+// Of every run of inputs as long as the total weight,
+// each task receives as many as its weight,
+// interleaved as evenly as possible.
+// The returned task is safe for concurrent use.
+// RoundRobin panics if tasks is empty.
+func RoundRobin[Input, Output any](tasks ...WeightedTask[Input, Output]) Task[Input, Output] {
+	next := newWeightedRoundRobin(tasks)
+	return func(in Input) (Output, error) {
+		return tasks[next()].Task(in)
+	}
+}
+
+// RoundRobinBy is like RoundRobin,
+// but inputs with the same key given by keyOf always go to the same task,
+// so that retries of an input reach the same backend.
+// The task for a key is chosen by weight the first time the key is seen
+// and remembered for as long as the returned task is in use.
+func RoundRobinBy[Input any, Key comparable, Output any](keyOf func(Input) Key, tasks ...WeightedTask[Input, Output]) Task[Input, Output] {
+	next := newWeightedRoundRobin(tasks)
+	var (
+		mu     sync.Mutex
+		chosen = make(map[Key]int)
+	)
+	return func(in Input) (Output, error) {
+		key := keyOf(in)
+		mu.Lock()
+		i, ok := chosen[key]
+		if !ok {
+			i = next()
+			chosen[key] = i
+		}
+		mu.Unlock()
+		return tasks[i].Task(in)
+	}
+}
+
+// newWeightedRoundRobin returns a function which picks the index of the next task
+// using smooth weighted round robin:
+// each pick adds every weight to its task's credit
+// and takes the total weight from the task with the most credit.
+func newWeightedRoundRobin[Input, Output any](tasks []WeightedTask[Input, Output]) func() int {
+	if len(tasks) == 0 {
+		panic("flowmatic: RoundRobin needs at least one task")
+	}
+	weights := make([]int, len(tasks))
+	total := 0
+	for i, t := range tasks {
+		weights[i] = max(t.Weight, 1)
+		total += weights[i]
+	}
+	var (
+		mu     sync.Mutex
+		credit = make([]int, len(tasks))
+	)
+	return func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		best := 0
+		for i, w := range weights {
+			credit[i] += w
+			if credit[i] > credit[best] {
+				best = i
+			}
+		}
+		credit[best] -= total
+		return best
+	}
+}
@@ -0,0 +1,42 @@
+package flowmatic_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+func TestRoundRobin(t *testing.T) {
+	backend := func(name string) flowmatic.Task[int, string] {
+		return func(int) (string, error) {
+			return name, nil
+		}
+	}
+	task := flowmatic.RoundRobin(
+		flowmatic.WeightedTask[int, string]{Weight: 3, Task: backend("a")},
+		flowmatic.WeightedTask[int, string]{Weight: 1, Task: backend("b")},
+	)
+	var sb strings.Builder
+	for i := range 8 {
+		out, _ := task(i)
+		sb.WriteString(out)
+	}
+	if s := sb.String(); s != "aaba"+"aaba" {
+		t.Fatal(s)
+	}
+
+	sticky := flowmatic.RoundRobinBy(func(n int) int { return n % 3 },
+		flowmatic.WeightedTask[int, string]{Weight: 1, Task: backend("a")},
+		flowmatic.WeightedTask[int, string]{Weight: 1, Task: backend("b")},
+	)
+	var got []string
+	for i := range 6 {
+		out, _ := sticky(i)
+		got = append(got, out)
+	}
+	if s := fmt.Sprint(got); s != "[a b a a b a]" {
+		t.Fatal(s)
+	}
+}
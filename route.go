@@ -0,0 +1,26 @@
+package flowmatic
+
+import (
+	"fmt"
+)
+
+// TaskRouter chooses the task to run for an input,
+// such as by its type or kind.
+type TaskRouter[Input, Output any] func(Input) Task[Input, Output]
+
+// Route returns a task which runs whichever task router chooses for each input,
+// so that one run can hand different kinds of input to different handlers,
+// like an HTTP mux for tasks.
+// Router is called in the worker just before the chosen task runs,
+// so it must be safe for concurrent use.
+// If router returns nil, the task returns an error wrapping ErrNoRoute.
+func Route[Input, Output any](router TaskRouter[Input, Output]) Task[Input, Output] {
+	return func(in Input) (Output, error) {
+		task := router(in)
+		if task == nil {
+			var zero Output
+			return zero, fmt.Errorf("%w: %v", ErrNoRoute, in)
+		}
+		return task(in)
+	}
+}
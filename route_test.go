@@ -0,0 +1,48 @@
+package flowmatic_test
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+func TestRoute(t *testing.T) {
+	double := func(in any) (string, error) {
+		return fmt.Sprint(2 * in.(int)), nil
+	}
+	shout := func(in any) (string, error) {
+		return in.(string) + "!", nil
+	}
+	task := flowmatic.Route(func(in any) flowmatic.Task[any, string] {
+		switch in.(type) {
+		case int:
+			return double
+		case string:
+			return shout
+		}
+		return nil
+	})
+	var (
+		outs    []string
+		noRoute []any
+	)
+	manager := func(in any, out string, err error) ([]any, bool) {
+		if errors.Is(err, flowmatic.ErrNoRoute) {
+			noRoute = append(noRoute, in)
+			return nil, true
+		}
+		outs = append(outs, out)
+		return nil, true
+	}
+	flowmatic.ManageTasks(2, task, manager, 1, "a", 2.5, 3, "b")
+	slices.Sort(outs)
+	if s := fmt.Sprint(outs); s != "[2 6 a! b!]" {
+		t.Fatal(s)
+	}
+	if s := fmt.Sprint(noRoute); s != "[2.5]" {
+		t.Fatal(s)
+	}
+}
@@ -0,0 +1,36 @@
+package flowmatic
+
+import (
+	"context"
+	"runtime/debug"
+)
+
+// GoGroup is a group of Goroutines which can run a function returning an error,
+// such as *errgroup.Group or *Group.
+type GoGroup interface {
+	Go(fn func() error)
+}
+
+// RunInGroup runs ManageTasksWith as a member of g,
+// so that an app which structures its work under an errgroup
+// can include a task management run.
+// Pass the context returned by errgroup.WithContext as ctx,
+// so that the run halts when another member of the group fails,
+// and so that the group is canceled if the run fails.
+// If a task or the manager panics,
+// the panic is returned to g as a *PanicError
+// instead of crashing the Goroutine run by g.
+func RunInGroup[Input, Output any](g GoGroup, ctx context.Context, numWorkers int, task CTask[Input, Output], manager Manager[Input, Output], initial []Input, opts ...Option) {
+	g.Go(func() (err error) {
+		defer func() {
+			if pval := recover(); pval != nil {
+				pe, ok := pval.(*PanicError)
+				if !ok {
+					pe = &PanicError{Value: pval, Stack: debug.Stack()}
+				}
+				err = pe
+			}
+		}()
+		return ManageTasksWith(ctx, numWorkers, task, manager, initial, opts...)
+	})
+}
@@ -0,0 +1,76 @@
+package flowmatic_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+// cancelGroup cancels its context when a function fails, like errgroup.WithContext.
+type cancelGroup struct {
+	wg     sync.WaitGroup
+	once   sync.Once
+	err    error
+	cancel context.CancelFunc
+}
+
+func (g *cancelGroup) Go(fn func() error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if err := fn(); err != nil {
+			g.once.Do(func() {
+				g.err = err
+				g.cancel()
+			})
+		}
+	}()
+}
+
+func (g *cancelGroup) Wait() error {
+	g.wg.Wait()
+	g.cancel()
+	return g.err
+}
+
+func TestRunInGroup(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	g := &cancelGroup{cancel: cancel}
+	// a member which runs until the group is canceled
+	g.Go(func() error {
+		<-ctx.Done()
+		return nil
+	})
+	task := func(_ context.Context, n int) (int, error) {
+		if n == 3 {
+			panic("three")
+		}
+		return n, nil
+	}
+	manager := func(in, out int, err error) ([]int, bool) {
+		return nil, true
+	}
+	flowmatic.RunInGroup(g, ctx, 2, task, manager, []int{1, 2, 3})
+	err := g.Wait()
+	var pe *flowmatic.PanicError
+	if !errors.As(err, &pe) || pe.Value != "three" || pe.Input != 3 {
+		t.Fatal(err)
+	}
+
+	// a failing member halts the run
+	ctx, cancel = context.WithCancel(context.Background())
+	g = &cancelGroup{cancel: cancel}
+	bad := errors.New("bad")
+	g.Go(func() error { return bad })
+	<-ctx.Done()
+	crawl := func(in, out int, err error) ([]int, bool) {
+		return []int{in + 1}, true
+	}
+	flowmatic.RunInGroup(g, ctx, 2, task, crawl, []int{10})
+	if err := g.Wait(); err != bad {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,54 @@
+package flowmatic
+
+import (
+	"context"
+	"os"
+	"os/signal"
+)
+
+// ManageTasksSignal is like ManageTasksResumable,
+// but it installs a handler for signals,
+// or for os.Interrupt if signals is empty,
+// so that a command line tool can be stopped gracefully.
+// The first signal halts processing like StopOn:
+// no more tasks are started,
+// the contexts of the running tasks are canceled,
+// and they are waited for.
+// A second signal gives up waiting for the running tasks.
+// Either way, ManageTasksSignal returns the inputs
+// which were never given to the manager,
+// so they can be saved and passed as the initial inputs of a later run.
+// The handler is uninstalled before ManageTasksSignal returns.
+func ManageTasksSignal[Input, Output any](signals []os.Signal, numWorkers int, task CTask[Input, Output], manager Manager[Input, Output], initial ...Input) (remaining []Input) {
+	if len(signals) == 0 {
+		signals = []os.Signal{os.Interrupt}
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, signals...)
+	defer signal.Stop(ch)
+	stop := make(chan struct{})
+	abandon := make(chan struct{})
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for _, c := range []chan struct{}{stop, abandon} {
+			select {
+			case <-ch:
+				close(c)
+			case <-done:
+				return
+			}
+		}
+	}()
+	remaining, _ = manageResumable(context.Background(), numWorkers, task, manager, initial,
+		StopOn(stop), withAbandon(abandon))
+	return remaining
+}
+
+// withAbandon makes the loop give up on in-flight tasks
+// once abandon is closed while it is waiting for them.
+func withAbandon(abandon <-chan struct{}) Option {
+	return func(o *options) {
+		o.abandon = abandon
+	}
+}
@@ -0,0 +1,58 @@
+package flowmatic_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+func TestManageTasksSignal(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("cannot send os.Interrupt on Windows")
+	}
+	self, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	interrupt := func() {
+		if err := self.Signal(os.Interrupt); err != nil {
+			t.Error(err)
+		}
+	}
+	block := make(chan struct{})
+	defer close(block)
+	task := func(ctx context.Context, n int) (int, error) {
+		if n == 2 {
+			// the signals are sent only once the run is stuck on this task,
+			// so the second signal cannot arrive after the handler is uninstalled
+			go func() {
+				interrupt()
+				time.Sleep(20 * time.Millisecond)
+				interrupt()
+			}()
+			// ignores cancelation
+			<-block
+		}
+		return n, nil
+	}
+	manager := func(in, out int, err error) ([]int, bool) {
+		return nil, true
+	}
+	done := make(chan []int)
+	go func() {
+		done <- flowmatic.ManageTasksSignal(nil, 1, task, manager, 1, 2, 3, 4)
+	}()
+	select {
+	case remaining := <-done:
+		if fmt.Sprint(remaining) != "[2 3 4]" {
+			t.Fatal(remaining)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("second signal did not force the run to return")
+	}
+}
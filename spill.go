@@ -0,0 +1,104 @@
+package flowmatic
+
+import (
+	"encoding/gob"
+	"errors"
+	"os"
+)
+
+// spill is a queue which keeps at most threshold items in memory.
+// Once that many are queued,
+// further items are gob encoded to a temporary file
+// until the file has been read back into memory.
+// Items from the file are queued behind the items in memory,
+// which preserves the order of a first-in, first-out queue.
+type spill[T any] struct {
+	queue[T]
+	dir       string
+	threshold int
+	// w and r are separate handles to the same file
+	// so that writing and reading have their own offsets.
+	w, r    *os.File
+	enc     *gob.Encoder
+	dec     *gob.Decoder
+	spilled int
+	err     error
+}
+
+func newSpill[T any](q queue[T], dir string, threshold int) *spill[T] {
+	return &spill[T]{queue: q, dir: dir, threshold: max(threshold, 1)}
+}
+
+func (q *spill[T]) push(items ...T) {
+	for _, item := range items {
+		if q.spilled == 0 && q.queue.len() < q.threshold {
+			q.queue.push(item)
+			continue
+		}
+		if q.err != nil {
+			return
+		}
+		if q.w == nil {
+			if q.err = q.open(); q.err != nil {
+				return
+			}
+		}
+		if q.err = q.enc.Encode(&item); q.err != nil {
+			return
+		}
+		q.spilled++
+	}
+}
+
+func (q *spill[T]) open() error {
+	w, err := os.CreateTemp(q.dir, "flowmatic-spill-*")
+	if err != nil {
+		return err
+	}
+	r, err := os.Open(w.Name())
+	if err != nil {
+		return errors.Join(err, w.Close(), os.Remove(w.Name()))
+	}
+	q.w, q.r = w, r
+	q.enc, q.dec = gob.NewEncoder(w), gob.NewDecoder(r)
+	return nil
+}
+
+// refill reads spilled items back into memory once memory is empty.
+func (q *spill[T]) refill() {
+	if q.queue.len() > 0 || q.spilled == 0 || q.err != nil {
+		return
+	}
+	for q.spilled > 0 && q.queue.len() < q.threshold {
+		var item T
+		if q.err = q.dec.Decode(&item); q.err != nil {
+			return
+		}
+		q.spilled--
+		q.queue.push(item)
+	}
+	if q.spilled == 0 {
+		q.err = q.close()
+	}
+}
+
+func (q *spill[T]) peek() (T, bool) {
+	q.refill()
+	return q.queue.peek()
+}
+
+func (q *spill[T]) len() int { return q.queue.len() + q.spilled }
+
+// failed returns the first error encountered while spilling.
+func (q *spill[T]) failed() error { return q.err }
+
+// close removes the temporary file, if there is one.
+func (q *spill[T]) close() error {
+	if q.w == nil {
+		return nil
+	}
+	err := errors.Join(q.w.Close(), q.r.Close(), os.Remove(q.w.Name()))
+	q.w, q.r, q.enc, q.dec = nil, nil, nil, nil
+	q.spilled = 0
+	return err
+}
@@ -0,0 +1,98 @@
+package flowmatic
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Stats is a snapshot of the state of the task management loop
+// taken just before the manager examines a result.
+type Stats struct {
+	// QueueLen is the number of queued inputs.
+	QueueLen int
+	// Inflight is the number of tasks which have been dispatched
+	// but whose results have not been received,
+	// not counting the result being examined.
+	Inflight int
+	// Completed is the number of results
+	// given to the manager before the one being examined.
+	Completed int
+}
+
+// StatsManager is like Manager,
+// but it also receives a Stats snapshot of the task management loop.
+type StatsManager[Input, Output any] func(Input, Output, error, Stats) (tasks []Input, ok bool)
+
+// ManageTasksStats is like ManageTasks,
+// but the manager also receives the current Stats of the loop,
+// so it can adapt to load,
+// for example by returning fewer inputs while the queue is long.
+// The Stats are read from the loop's own counters in the Goroutine running the manager,
+// so they are always consistent with what the manager has seen.
+func ManageTasksStats[Input, Output any](numWorkers int, task Task[Input, Output], manager StatsManager[Input, Output], initial ...Input) {
+	var stats Stats
+	m := func(in Input, out Output, err error) ([]Input, bool) {
+		return manager(in, out, err, stats)
+	}
+	_ = rethrow(manage(context.Background(), numWorkers, ignoreContext(task), m, newFIFO(initial...), withStats(&stats)))
+}
+
+// RunStats summarizes a run of ManageTasksRunStats.
+type RunStats struct {
+	// Completed is the number of results given to the manager.
+	Completed int
+	// Errored is the number of tasks which returned an error
+	// other than ErrSkip.
+	Errored int
+	// Panicked is the number of tasks which panicked.
+	Panicked int
+	// Duration is how long the run took.
+	Duration time.Duration
+	// MaxQueueDepth is the largest number of inputs queued at once.
+	// It can help choose a value for MaxQueue.
+	MaxQueueDepth int
+	// MaxInflight is the largest number of tasks running at once.
+	MaxInflight int
+}
+
+// ManageTasksRunStats is like ManageTasksWith,
+// but it also returns a summary of the run.
+// The counts are kept by the loop itself,
+// so they cost little and need no instrumentation in the manager.
+func ManageTasksRunStats[Input, Output any](ctx context.Context, numWorkers int, task CTask[Input, Output], manager Manager[Input, Output], initial []Input, opts ...Option) (RunStats, error) {
+	var rs RunStats
+	o := buildOptions(opts)
+	clock := o.clock()
+	start := clock.Now()
+	err := rethrow(manage(ctx, numWorkers, task, manager, newFIFO(initial...), append(opts[:len(opts):len(opts)], withRunStats(&rs))...))
+	rs.Duration = clock.Now().Sub(start)
+	return rs, err
+}
+
+// withRunStats makes the loop keep rs up to date.
+func withRunStats(rs *RunStats) Option {
+	return func(o *options) {
+		o.runStats = rs
+	}
+}
+
+// recordDepth notes the queue depth and inflight tasks for RunStats.
+func (l *loop[Input, Output]) recordDepth(inflight int) {
+	if rs := l.opts.runStats; rs != nil {
+		rs.MaxQueueDepth = max(rs.MaxQueueDepth, l.queue.len())
+		rs.MaxInflight = max(rs.MaxInflight, inflight)
+	}
+}
+
+// recordResult counts the errors and panics of results for RunStats.
+func (l *loop[Input, Output]) recordResult(r Result[Input, Output]) {
+	rs := l.opts.runStats
+	switch {
+	case rs == nil:
+	case r.Panic != nil:
+		rs.Panicked++
+	case r.Err != nil && !errors.Is(r.Err, ErrSkip):
+		rs.Errored++
+	}
+}
@@ -0,0 +1,91 @@
+package flowmatic_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+func TestManageTasksStats(t *testing.T) {
+	task := func(n int) (int, error) {
+		return n, nil
+	}
+	calls := 0
+	manager := func(in, out int, err error, stats flowmatic.Stats) ([]int, bool) {
+		if stats.Completed != calls {
+			t.Fatal(stats, calls)
+		}
+		calls++
+		if stats.Inflight < 0 || stats.Inflight > 4 {
+			t.Fatal(stats)
+		}
+		// stop expanding once the backlog is long
+		if stats.QueueLen > 10 || in > 5 {
+			return nil, true
+		}
+		return []int{in + 1, in + 1, in + 1}, true
+	}
+	flowmatic.ManageTasksStats(2, task, manager, 0)
+	if calls < 10 {
+		t.Fatal(calls)
+	}
+}
+
+func TestManageTasksStats_singleWorker(t *testing.T) {
+	task := func(n int) (int, error) {
+		return n, nil
+	}
+	var got []flowmatic.Stats
+	manager := func(in, out int, err error, stats flowmatic.Stats) ([]int, bool) {
+		got = append(got, stats)
+		return nil, true
+	}
+	flowmatic.ManageTasksStats(1, task, manager, 1, 2, 3)
+	// the single worker may have taken the next input before the manager runs
+	for i, stats := range got {
+		if stats.Completed != i || stats.QueueLen+stats.Inflight != 2-i {
+			t.Fatal(i, stats)
+		}
+	}
+}
+
+func TestManageTasksRunStats(t *testing.T) {
+	task := func(_ context.Context, n int) (int, error) {
+		switch {
+		case n%5 == 1:
+			return 0, errors.New("bad")
+		case n%5 == 2:
+			panic(n)
+		}
+		time.Sleep(time.Millisecond)
+		return n, nil
+	}
+	manager := func(in, out int, err error) ([]int, bool) {
+		return nil, true
+	}
+	rs, err := flowmatic.ManageTasksRunStats(context.Background(), 3, task, manager,
+		make([]int, 20), flowmatic.OnPanic(flowmatic.Ignore))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rs.Completed != 20 || rs.Errored != 0 || rs.Panicked != 0 {
+		t.Fatal(rs)
+	}
+	if rs.MaxQueueDepth != 20 || rs.MaxInflight != 3 || rs.Duration <= 0 {
+		t.Fatal(rs)
+	}
+	inputs := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+	rs, err = flowmatic.ManageTasksRunStats(context.Background(), 3, task, manager,
+		inputs, flowmatic.OnPanic(flowmatic.Ignore))
+	if err != nil || rs.Completed != 10 || rs.Errored != 2 || rs.Panicked != 2 {
+		t.Fatal(err, rs)
+	}
+	rs, err = flowmatic.ManageTasksRunStats(context.Background(), 3, task, manager,
+		inputs, flowmatic.OnPanic(flowmatic.Ignore), flowmatic.Synchronous())
+	if err != nil || rs.Completed != 10 || rs.Panicked != 2 || rs.MaxInflight != 1 {
+		t.Fatal(err, rs)
+	}
+}
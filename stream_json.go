@@ -0,0 +1,34 @@
+package flowmatic
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+)
+
+// StreamJSON starts numWorkers concurrent workers (or GOMAXPROCS workers if numWorkers < 1)
+// and runs task once for each input.
+// As tasks complete,
+// StreamJSON writes each output to w as newline-delimited JSON.
+// Writes are made serially, so outputs are never interleaved.
+// The first error returned by a task or by encoding an output
+// halts further task scheduling
+// and is returned once the running tasks have finished.
+// If a task panics during execution,
+// the panic will be caught and rethrown in the parent Goroutine.
+func StreamJSON[Input, Output any](w io.Writer, numWorkers int, task Task[Input, Output], inputs ...Input) error {
+	enc := json.NewEncoder(w)
+	var firstErr error
+	manager := func(_ Input, out Output, err error) ([]Input, bool) {
+		if err == nil {
+			err = enc.Encode(out)
+		}
+		if err != nil {
+			firstErr = err
+			return nil, false
+		}
+		return nil, true
+	}
+	_ = rethrow(manage(context.Background(), numWorkers, ignoreContext(task), manager, newFIFO(inputs...)))
+	return firstErr
+}
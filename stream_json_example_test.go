@@ -0,0 +1,40 @@
+package flowmatic_test
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"slices"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+func ExampleStreamJSON() {
+	type square struct {
+		N      int `json:"n"`
+		Square int `json:"square"`
+	}
+	task := func(n int) (square, error) {
+		return square{n, n * n}, nil
+	}
+	var buf bytes.Buffer
+	err := flowmatic.StreamJSON(&buf, 3, task, 1, 2, 3, 4)
+	if err != nil {
+		fmt.Println(err)
+	}
+	// Lines are written in completion order
+	var lines []string
+	s := bufio.NewScanner(&buf)
+	for s.Scan() {
+		lines = append(lines, s.Text())
+	}
+	slices.Sort(lines)
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+	// Output:
+	// {"n":1,"square":1}
+	// {"n":2,"square":4}
+	// {"n":3,"square":9}
+	// {"n":4,"square":16}
+}
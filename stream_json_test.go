@@ -0,0 +1,30 @@
+package flowmatic_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+func TestStreamJSON_err(t *testing.T) {
+	var buf bytes.Buffer
+	// channels cannot be encoded
+	err := flowmatic.StreamJSON(&buf, 1, func(int) (any, error) {
+		return make(chan int), nil
+	}, 1, 2)
+	if err == nil || buf.Len() != 0 {
+		t.Fatal(err, buf.String())
+	}
+	bad := errors.New("bad")
+	err = flowmatic.StreamJSON(&buf, 1, func(n int) (int, error) {
+		if n == 2 {
+			return 0, bad
+		}
+		return n, nil
+	}, 1, 2, 3)
+	if err != bad || buf.String() != "1\n" {
+		t.Fatal(err, buf.String())
+	}
+}
@@ -0,0 +1,62 @@
+package flowmatic
+
+import (
+	"context"
+)
+
+// ManageTasksTake is like ManageTasks,
+// but it halts processing once count tasks have succeeded
+// and returns their outputs in the order they completed.
+// The manager sees every result up to and including the last successful one.
+// Tasks still running when the count is reached are waited for,
+// but their results are discarded,
+// so exactly count outputs are returned
+// unless the queue is exhausted or the manager halts processing first.
+// If a task panics during execution,
+// the panic will be caught and rethrown in the parent Goroutine.
+func ManageTasksTake[Input, Output any](numWorkers, count int, task Task[Input, Output], manager Manager[Input, Output], initial ...Input) []Output {
+	if count < 1 {
+		return nil
+	}
+	outputs := make([]Output, 0, count)
+	m := func(in Input, out Output, err error) ([]Input, bool) {
+		items, ok := manager(in, out, err)
+		if err == nil {
+			outputs = append(outputs, out)
+			if len(outputs) == count {
+				return nil, false
+			}
+		}
+		return items, ok
+	}
+	_ = rethrow(manage(context.Background(), numWorkers, ignoreContext(task), m, newFIFO(initial...)))
+	return outputs
+}
+
+// ManageTasksUntil is like ManageTasks,
+// but it halts processing as soon as a task result satisfies stop
+// and returns that result,
+// such as to crawl until a page containing some text is found.
+// The manager sees every result up to and including the matching one.
+// If several running tasks would satisfy stop,
+// the first to complete is returned;
+// tasks still running are waited for,
+// but their results are discarded.
+// If the queue is exhausted or the manager halts processing
+// before any result satisfies stop,
+// ok is false.
+// If a task panics during execution,
+// the panic will be caught and rethrown in the parent Goroutine.
+func ManageTasksUntil[Input, Output any](numWorkers int, stop func(Output, error) bool, task Task[Input, Output], manager Manager[Input, Output], initial ...Input) (r Result[Input, Output], ok bool) {
+	m := func(in Input, out Output, err error) ([]Input, bool) {
+		items, cont := manager(in, out, err)
+		if stop(out, err) {
+			r = Result[Input, Output]{In: in, Out: out, Err: err}
+			ok = true
+			return nil, false
+		}
+		return items, cont
+	}
+	_ = rethrow(manage(context.Background(), numWorkers, ignoreContext(task), m, newFIFO(initial...)))
+	return r, ok
+}
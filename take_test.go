@@ -0,0 +1,69 @@
+package flowmatic_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+func TestManageTasksTake(t *testing.T) {
+	bad := errors.New("bad")
+	task := func(n int) (int, error) {
+		if n%3 == 0 {
+			return 0, bad
+		}
+		return n, nil
+	}
+	// an endless crawl
+	manager := func(in, out int, err error) ([]int, bool) {
+		return []int{in + 1, in + 2}, true
+	}
+	for range 10 {
+		outputs := flowmatic.ManageTasksTake(4, 7, task, manager, 1)
+		if len(outputs) != 7 {
+			t.Fatal(outputs)
+		}
+		for _, out := range outputs {
+			if out%3 == 0 {
+				t.Fatal(outputs)
+			}
+		}
+	}
+	noop := func(in, out int, err error) ([]int, bool) {
+		return nil, true
+	}
+	// the queue runs out first
+	if outputs := flowmatic.ManageTasksTake(4, 7, task, noop, 1, 2, 3); len(outputs) != 2 {
+		t.Fatal(outputs)
+	}
+}
+
+func TestManageTasksUntil(t *testing.T) {
+	task := func(n int) (int, error) {
+		return n * n, nil
+	}
+	seen := 0
+	// an endless crawl
+	manager := func(in, out int, err error) ([]int, bool) {
+		seen++
+		return []int{in + 1}, true
+	}
+	stop := func(out int, err error) bool {
+		return out > 100
+	}
+	r, ok := flowmatic.ManageTasksUntil(4, stop, task, manager, 1, 2, 3)
+	if !ok || r.Out != r.In*r.In || r.Out <= 100 {
+		t.Fatal(r, ok)
+	}
+	if seen < 9 {
+		t.Fatal(seen)
+	}
+	noop := func(in, out int, err error) ([]int, bool) {
+		return nil, true
+	}
+	// the queue runs out first
+	if r, ok := flowmatic.ManageTasksUntil(4, stop, task, noop, 1, 2, 3); ok {
+		t.Fatal(r)
+	}
+}
@@ -0,0 +1,22 @@
+package flowmatic
+
+import (
+	"context"
+	"iter"
+)
+
+// TaskSeq returns an iterator which starts numWorkers concurrent workers (or GOMAXPROCS workers if numWorkers < 1),
+// runs task once for each initial input,
+// and yields the outputs and errors in the order the tasks complete.
+// Breaking out of the loop halts further task scheduling
+// and waits for the running tasks to finish before the loop exits.
+// If a task panics during execution,
+// the panic will be caught and rethrown in the Goroutine ranging over the iterator.
+func TaskSeq[Input, Output any](numWorkers int, task Task[Input, Output], initial ...Input) iter.Seq2[Output, error] {
+	return func(yield func(Output, error) bool) {
+		manager := func(_ Input, out Output, err error) ([]Input, bool) {
+			return nil, yield(out, err)
+		}
+		_ = rethrow(manage(context.Background(), numWorkers, ignoreContext(task), manager, newFIFO(initial...)))
+	}
+}
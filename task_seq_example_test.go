@@ -0,0 +1,24 @@
+package flowmatic_test
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+func ExampleTaskSeq() {
+	var results []string
+	for n, err := range flowmatic.TaskSeq(flowmatic.MaxProcs, strconv.Atoi, "1", "2", "three", "4") {
+		if err != nil {
+			results = append(results, "error")
+			continue
+		}
+		results = append(results, fmt.Sprint(n))
+	}
+	slices.Sort(results)
+	fmt.Println(results)
+	// Output:
+	// [1 2 4 error]
+}
@@ -0,0 +1,37 @@
+package flowmatic_test
+
+import (
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+func TestTaskSeq_break(t *testing.T) {
+	before := runtime.NumGoroutine()
+	var started atomic.Int64
+	task := func(n int) (int, error) {
+		started.Add(1)
+		time.Sleep(time.Millisecond)
+		return n, nil
+	}
+	inputs := make([]int, 100)
+	seen := 0
+	for range flowmatic.TaskSeq(2, task, inputs...) {
+		seen++
+		if seen == 3 {
+			break
+		}
+	}
+	if seen != 3 {
+		t.Fatal(seen)
+	}
+	if n := started.Load(); n > 10 {
+		t.Fatal(n)
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Fatal(before, after)
+	}
+}
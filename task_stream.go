@@ -0,0 +1,79 @@
+package flowmatic
+
+import (
+	"context"
+
+	"github.com/carlmjohnson/deque"
+)
+
+// TaskStream starts numWorkers concurrent workers (or GOMAXPROCS workers if numWorkers < 1)
+// which execute task for each initial input and for each input sent on the in channel.
+// Results are sent on the out channel in the order they complete.
+// Inputs sent on in are queued without limit,
+// so it is safe to send new inputs while ranging over out.
+//
+// Callers should close the in channel once they have no more inputs to submit.
+// The out channel is closed once in has been closed
+// and every queued task has finished and had its result received.
+// To stop early, cancel ctx.
+// Once ctx is canceled, queued inputs are discarded,
+// in-flight tasks are waited for,
+// their results are discarded,
+// and out is closed.
+// Callers must not send on in after canceling ctx.
+//
+// Panics are not rethrown, but are reported in the Panic field of the Result.
+func TaskStream[Input, Output any](ctx context.Context, numWorkers int, task Task[Input, Output], initial ...Input) (in chan<- Input, out <-chan Result[Input, Output]) {
+	inch := make(chan Input)
+	ouch := make(chan Result[Input, Output])
+	go func() {
+		defer close(ouch)
+		poolin, poolout := TaskPool(numWorkers, task)
+		defer func() {
+			close(poolin)
+			// drain any waiting tasks
+			for range poolout {
+			}
+		}()
+		var (
+			src      <-chan Input = inch
+			queue                 = deque.Of(initial...)
+			results               = deque.Make[Result[Input, Output]](0)
+			inflight              = 0
+		)
+		for src != nil || queue.Len() > 0 || inflight > 0 || results.Len() > 0 {
+			if ctx.Err() != nil {
+				return
+			}
+			dispatch := poolin
+			item, ok := queue.Head()
+			if !ok {
+				dispatch = nil
+			}
+			deliver := ouch
+			r, ok := results.Head()
+			if !ok {
+				deliver = nil
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case v, ok := <-src:
+				if !ok {
+					src = nil
+					continue
+				}
+				queue.PushBack(v)
+			case dispatch <- item:
+				inflight++
+				queue.RemoveFront()
+			case r := <-poolout:
+				inflight--
+				results.PushBack(r)
+			case deliver <- r:
+				results.RemoveFront()
+			}
+		}
+	}()
+	return inch, ouch
+}
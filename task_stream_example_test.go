@@ -0,0 +1,35 @@
+package flowmatic_test
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+func ExampleTaskStream() {
+	double := func(n int) (int, error) {
+		return 2 * n, nil
+	}
+	in, out := flowmatic.TaskStream(context.Background(), flowmatic.MaxProcs, double, 1)
+	// Keep track of how many results are still expected,
+	// so we know when to close the in channel.
+	pending := 1
+	for r := range out {
+		pending--
+		fmt.Println(r.In, "doubled is", r.Out)
+		if r.Out < 20 {
+			pending++
+			in <- r.Out
+		}
+		if pending == 0 {
+			close(in)
+		}
+	}
+	// Output:
+	// 1 doubled is 2
+	// 2 doubled is 4
+	// 4 doubled is 8
+	// 8 doubled is 16
+	// 16 doubled is 32
+}
@@ -0,0 +1,30 @@
+package flowmatic_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+func TestTaskStream_cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	task := func(n int) (int, error) {
+		time.Sleep(time.Millisecond)
+		return n, nil
+	}
+	_, out := flowmatic.TaskStream(ctx, 2, task, 0, 1, 2, 3, 4, 5, 6, 7, 8, 9)
+	n := 0
+	for range out {
+		n++
+		if n == 2 {
+			// stop early without closing in
+			cancel()
+		}
+	}
+	if n >= 10 {
+		t.Fatal(n)
+	}
+}
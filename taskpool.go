@@ -3,6 +3,7 @@ package flowmatic
 import (
 	"runtime"
 	"sync"
+	"time"
 )
 
 // Result is the type returned by the output channel of TaskPool.
@@ -11,45 +12,167 @@ type Result[Input, Output any] struct {
 	Out   Output
 	Err   error
 	Panic any
+	// Stack is the stack trace of the task if it panicked.
+	Stack []byte
+	// Duration is how long the task ran.
+	Duration time.Duration
+	// WorkerID identifies the worker which ran the task,
+	// numbered from 0 to numWorkers-1.
+	WorkerID int
 }
 
 // TaskPool starts numWorkers workers (or GOMAXPROCS workers if numWorkers < 1) which consume
 // the in channel, execute task, and send the Result on the out channel.
 // Callers should close the in channel to stop the workers from waiting for tasks.
 // The out channel will be closed once the last result has been sent.
+//
+// TaskPool is the primitive the rest of the package is built on,
+// and it can be used to implement other scheduling disciplines.
+// The caller owns in and must close it exactly once.
+// The caller must also keep receiving from out until it is closed;
+// out is buffered for numWorkers results,
+// so a caller which stops receiving early
+// leaves the workers blocked and leaks their Goroutines.
+// A panicking task does not stop its worker;
+// the panic is reported in the Result.
 func TaskPool[Input, Output any](numWorkers int, task Task[Input, Output]) (in chan<- Input, out <-chan Result[Input, Output]) {
+	return taskPool(numWorkers, 0, true, task)
+}
+
+// Drain receives and discards the remaining results from out,
+// the out channel of a TaskPool,
+// until it is closed.
+// Callers which stop using a TaskPool's results early
+// should close its in channel and then call Drain,
+// so that the workers can deliver their last results and exit.
+func Drain[Input, Output any](out <-chan Result[Input, Output]) {
+	for range out {
+	}
+}
+
+// DrainFunc is like Drain,
+// but it calls fn with each remaining result.
+func DrainFunc[Input, Output any](out <-chan Result[Input, Output], fn func(Result[Input, Output])) {
+	for r := range out {
+		fn(r)
+	}
+}
+
+// taskPool is TaskPool with the out channel buffered for outBuffer results,
+// or for numWorkers results if outBuffer < 1.
+// If catch is false, panicking tasks are not recovered.
+func taskPool[Input, Output any](numWorkers, outBuffer int, catch bool, task Task[Input, Output]) (in chan<- Input, out <-chan Result[Input, Output]) {
 	if numWorkers < 1 {
 		numWorkers = runtime.GOMAXPROCS(0)
 	}
-	inch := make(chan Input)
-	ouch := make(chan Result[Input, Output], numWorkers)
-	var wg sync.WaitGroup
-	wg.Add(numWorkers)
-	for i := 0; i < numWorkers; i++ {
-		go func() {
-			defer wg.Done()
-			for inval := range inch {
-				func() {
-					defer func() {
-						pval := recover()
-						if pval == nil {
-							return
-						}
-						ouch <- Result[Input, Output]{
-							In:    inval,
-							Panic: pval,
-						}
-					}()
-
-					outval, err := task(inval)
-					ouch <- Result[Input, Output]{inval, outval, err, nil}
-				}()
-			}
-		}()
+	p := newWorkerPool(numWorkers, outBuffer, catch, task)
+	for p.grow() {
+	}
+	go p.wait()
+	return p.in, p.out
+}
+
+// workerPool is a TaskPool whose workers are started one at a time,
+// so that a large pool doing little work
+// does not start Goroutines which would sit idle.
+type workerPool[Input, Output any] struct {
+	in      chan Input
+	out     chan Result[Input, Output]
+	run     func(int, Task[Input, Output], Input, *options) Result[Input, Output]
+	task    Task[Input, Output]
+	opts    *options
+	wg      sync.WaitGroup
+	started int
+	max     int
+	// worker, if set, returns the task run by worker id in place of task
+	// and a function to call once that worker exits.
+	worker func(id int) (Task[Input, Output], func())
+}
+
+// newWorkerPool returns a pool of up to numWorkers workers,
+// none of which have been started,
+// with its out channel buffered like taskPool.
+func newWorkerPool[Input, Output any](numWorkers, outBuffer int, catch bool, task Task[Input, Output]) *workerPool[Input, Output] {
+	run := runTask[Input, Output]
+	if !catch {
+		run = runTaskUncaught[Input, Output]
 	}
+	if outBuffer < 1 {
+		outBuffer = numWorkers
+	}
+	return &workerPool[Input, Output]{
+		in:   make(chan Input),
+		out:  make(chan Result[Input, Output], outBuffer),
+		run:  run,
+		task: task,
+		opts: &options{},
+		max:  numWorkers,
+	}
+}
+
+// grow starts another worker
+// and reports whether there was room for it.
+// It must not be called after in is closed.
+func (p *workerPool[Input, Output]) grow() bool {
+	if p.started >= p.max {
+		return false
+	}
+	id := p.started
+	p.started++
+	p.wg.Add(1)
 	go func() {
-		wg.Wait()
-		close(ouch)
+		defer p.wg.Done()
+		task := p.task
+		if p.worker != nil {
+			var exit func()
+			task, exit = p.worker(id)
+			defer exit()
+		}
+		for inval := range p.in {
+			p.out <- p.run(id, task, inval, p.opts)
+		}
+	}()
+	return true
+}
+
+// wait closes out once in has been closed
+// and every started worker has returned.
+func (p *workerPool[Input, Output]) wait() {
+	p.wg.Wait()
+	close(p.out)
+}
+
+// runTask runs task on worker id, recovering any panic into the Result.
+// The stack and duration are measured as set by o.
+func runTask[Input, Output any](id int, task Task[Input, Output], in Input, o *options) (r Result[Input, Output]) {
+	clock := o.clock()
+	start := clock.Now()
+	defer func() {
+		if pval := recover(); pval != nil {
+			r = Result[Input, Output]{
+				In:    in,
+				Panic: pval,
+				Stack: o.captureStack(),
+			}
+		}
+		r.Duration = clock.Now().Sub(start)
+		r.WorkerID = id
 	}()
-	return inch, ouch
+	out, err := task(in)
+	return Result[Input, Output]{In: in, Out: out, Err: err}
+}
+
+// runTaskUncaught is like runTask,
+// but it lets a panicking task crash the program.
+func runTaskUncaught[Input, Output any](id int, task Task[Input, Output], in Input, o *options) Result[Input, Output] {
+	clock := o.clock()
+	start := clock.Now()
+	out, err := task(in)
+	return Result[Input, Output]{
+		In:       in,
+		Out:      out,
+		Err:      err,
+		Duration: clock.Now().Sub(start),
+		WorkerID: id,
+	}
 }
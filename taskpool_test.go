@@ -0,0 +1,78 @@
+package flowmatic_test
+
+import (
+	"testing"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+func TestTaskPool(t *testing.T) {
+	checkGoroutines(t, "TaskPool", func() {
+		in, out := flowmatic.TaskPool(3, func(n int) (int, error) {
+			if n == 5 {
+				panic("5!!")
+			}
+			return n * 2, nil
+		})
+		go func() {
+			defer close(in)
+			for i := range 10 {
+				in <- i
+			}
+		}()
+		sum, panics := 0, 0
+		workers := map[int]bool{}
+		for r := range out {
+			workers[r.WorkerID] = true
+			if r.Panic != nil {
+				panics++
+				if r.In != 5 || len(r.Stack) == 0 {
+					t.Fatal(r)
+				}
+				continue
+			}
+			if r.Out != r.In*2 {
+				t.Fatal(r)
+			}
+			sum += r.Out
+		}
+		if sum != 80 || panics != 1 {
+			t.Fatal(sum, panics)
+		}
+		for id := range workers {
+			if id < 0 || id >= 3 {
+				t.Fatal(id)
+			}
+		}
+	})
+}
+
+func TestDrain(t *testing.T) {
+	checkGoroutines(t, "Drain", func() {
+		in, out := flowmatic.TaskPool(3, func(n int) (int, error) {
+			return n, nil
+		})
+		go func() {
+			defer close(in)
+			for i := range 100 {
+				in <- i
+			}
+		}()
+		// stop after the first result
+		first := <-out
+		sum := first.Out
+		flowmatic.DrainFunc(out, func(r flowmatic.Result[int, int]) {
+			sum += r.Out
+		})
+		if sum != 4950 {
+			t.Fatal(sum)
+		}
+
+		in, out = flowmatic.TaskPool(3, func(n int) (int, error) {
+			return n, nil
+		})
+		in <- 1
+		close(in)
+		flowmatic.Drain(out)
+	})
+}
@@ -0,0 +1,26 @@
+package flowmatic
+
+// Tee returns a Manager which gives each result to every one of managers in turn,
+// so that separate concerns, such as expanding the crawl and recording results,
+// can be written as separate managers.
+// The inputs returned by all of the managers are queued together,
+// in the order of managers,
+// and processing halts if any of the managers returns false.
+// Every manager sees every result,
+// even after an earlier manager has returned false for it.
+// A nil manager is treated as NoExpand.
+func Tee[Input, Output any](managers ...Manager[Input, Output]) Manager[Input, Output] {
+	return func(in Input, out Output, err error) ([]Input, bool) {
+		var items []Input
+		ok := true
+		for _, m := range managers {
+			if m == nil {
+				continue
+			}
+			newItems, mok := m(in, out, err)
+			items = append(items, newItems...)
+			ok = ok && mok
+		}
+		return items, ok
+	}
+}
@@ -0,0 +1,38 @@
+package flowmatic_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+func TestTee(t *testing.T) {
+	task := func(n int) (int, error) {
+		return n * n, nil
+	}
+	var squares []int
+	record := func(in, out int, err error) ([]int, bool) {
+		squares = append(squares, out)
+		return nil, true
+	}
+	expand := func(in, out int, err error) ([]int, bool) {
+		if in < 4 {
+			return []int{in + 1}, true
+		}
+		return nil, true
+	}
+	stop := func(in, out int, err error) ([]int, bool) {
+		return nil, in < 3
+	}
+	flowmatic.ManageTasks(1, task, flowmatic.Tee(record, expand, nil), 1)
+	if s := fmt.Sprint(squares); s != "[1 4 9 16]" {
+		t.Fatal(s)
+	}
+	squares = nil
+	flowmatic.ManageTasks(1, task, flowmatic.Tee(expand, stop, record), 1)
+	// record still sees the result which halts processing
+	if s := fmt.Sprint(squares); s != "[1 4 9]" {
+		t.Fatal(s)
+	}
+}
@@ -0,0 +1,49 @@
+package flowmatic
+
+import (
+	"time"
+)
+
+// WithTimeout wraps task so that it returns ErrTaskTimeout
+// if it runs for longer than d.
+// The wrapped task runs in its own Goroutine,
+// so a timed out task returns immediately
+// and frees up its worker even if the underlying task ignores cancelation.
+// The underlying task keeps running in the background until it finishes,
+// so a task which never returns will leak its Goroutine.
+// Its eventual result is discarded,
+// and a panic after the timeout has expired is silently dropped.
+// The task is timed with the Clock set by the WithClock option, if any.
+func WithTimeout[Input, Output any](d time.Duration, task Task[Input, Output], opts ...Option) Task[Input, Output] {
+	o := buildOptions(opts)
+	c := o.clock()
+	type result struct {
+		out   Output
+		err   error
+		panic any
+	}
+	return func(in Input) (out Output, err error) {
+		// buffered so an abandoned task can still send its result and exit
+		ch := make(chan result, 1)
+		go func() {
+			defer func() {
+				if pval := recover(); pval != nil {
+					ch <- result{panic: pval}
+				}
+			}()
+			out, err := task(in)
+			ch <- result{out: out, err: err}
+		}()
+		timer := c.NewTimer(d)
+		defer timer.Stop()
+		select {
+		case r := <-ch:
+			if r.panic != nil {
+				panic(r.panic)
+			}
+			return r.out, r.err
+		case <-timer.C():
+			return out, ErrTaskTimeout
+		}
+	}
+}
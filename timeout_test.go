@@ -0,0 +1,41 @@
+package flowmatic_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+func TestWithTimeout(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+	task := flowmatic.WithTimeout(10*time.Millisecond, func(n int) (int, error) {
+		if n == 1 {
+			// ignores cancelation
+			<-block
+		}
+		return n, nil
+	})
+	start := time.Now()
+	if out, err := task(0); out != 0 || err != nil {
+		t.Fatal(out, err)
+	}
+	_, err := task(1)
+	if !errors.Is(err, flowmatic.ErrTaskTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatal(err)
+	}
+	if time.Since(start) > time.Second {
+		t.Fatal("did not unblock")
+	}
+	r := try(func() {
+		_, _ = flowmatic.WithTimeout(time.Second, func(int) (int, error) {
+			panic("boom")
+		})(0)
+	})
+	if r != "boom" {
+		t.Fatal(r)
+	}
+}
@@ -0,0 +1,86 @@
+package flowmatic
+
+import (
+	"context"
+	"errors"
+	"math"
+	"runtime"
+)
+
+// ManageTasksWeighted is like ManageTasks,
+// but instead of a fixed number of workers,
+// tasks are admitted while their combined weight fits within totalWeight,
+// like a weighted semaphore.
+// Queued inputs are admitted in first-in, first-out order,
+// so a heavy input at the head of the queue
+// waits for enough running tasks to finish
+// rather than being overtaken by lighter inputs.
+// An input heavier than totalWeight runs alone
+// once every other task has finished.
+// Weights less than 1 are treated as 1.
+// WeightOf is only called from the Goroutine which called ManageTasksWeighted.
+// A nil manager is treated as NoExpand.
+// ManageTasksWeighted panics if totalWeight is less than 1.
+func ManageTasksWeighted[Input, Output any](totalWeight int64, weightOf func(Input) int64, task Task[Input, Output], manager Manager[Input, Output], initial ...Input) {
+	if totalWeight < 1 {
+		panic("flowmatic: totalWeight must be positive")
+	}
+	if manager == nil {
+		manager = NoExpand[Input, Output]()
+	}
+	weigh := func(items []Input) []weighed[Input] {
+		wrapped := make([]weighed[Input], len(items))
+		for i, item := range items {
+			wrapped[i] = weighed[Input]{min(max(weightOf(item), 1), totalWeight), item}
+		}
+		return wrapped
+	}
+	q := &weights[Input]{fifo: newFIFO(weigh(initial)...), total: totalWeight}
+	m := func(r Result[weighed[Input], Output]) ([]weighed[Input], Action) {
+		q.used -= r.In.w
+		if errors.Is(r.Err, ErrSkip) {
+			return nil, Continue
+		}
+		items, ok := manager(r.In.val, r.Out, r.Err)
+		return weigh(items), continueIf(ok)
+	}
+	t := func(_ context.Context, in weighed[Input]) (Output, error) {
+		return task(in.val)
+	}
+	// every admitted task has a weight of at least 1,
+	// so no more than totalWeight tasks run at once
+	numWorkers := int(min(totalWeight, math.MaxInt32))
+	_ = rethrow(manageFunc(context.Background(), numWorkers, t, m, q,
+		keepSkips(), OutputBuffer(min(numWorkers, runtime.GOMAXPROCS(0)))))
+}
+
+// weighed is a value tagged with its weight.
+type weighed[T any] struct {
+	w   int64
+	val T
+}
+
+func (w weighed[T]) unwrapInput() any { return w.val }
+
+// weights is a first-in, first-out queue
+// which only dispatches an item
+// while its weight fits within the total weight left by the items in use.
+// Its user must subtract the weight of an item from used once it is done.
+type weights[T any] struct {
+	*fifo[weighed[T]]
+	total, used int64
+}
+
+func (q *weights[T]) peek() (t weighed[T], ok bool) {
+	t, ok = q.fifo.peek()
+	if ok && q.used+t.w > q.total {
+		return weighed[T]{}, false
+	}
+	return t, ok
+}
+
+func (q *weights[T]) pop() {
+	t, _ := q.fifo.peek()
+	q.used += t.w
+	q.fifo.pop()
+}
@@ -0,0 +1,109 @@
+package flowmatic_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+func TestManageTasksWeighted(t *testing.T) {
+	var cur, peak atomic.Int64
+	var big atomic.Bool
+	task := func(w int64) (int64, error) {
+		n := cur.Add(w)
+		defer cur.Add(-w)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		if w > 10 {
+			if n != w {
+				t.Errorf("oversized task ran alongside others: %d", n)
+			}
+			big.Store(true)
+		}
+		time.Sleep(time.Millisecond)
+		return w, nil
+	}
+	weightOf := func(w int64) int64 { return w }
+	count := 0
+	manager := func(in, out int64, err error) ([]int64, bool) {
+		count++
+		return nil, true
+	}
+	flowmatic.ManageTasksWeighted(10, weightOf, task, manager,
+		1, 1, 1, 5, 5, 1, 1, 20, 1, 1, 9, 1)
+	if count != 12 {
+		t.Fatal(count)
+	}
+	if !big.Load() {
+		t.Fatal("oversized task did not run")
+	}
+	if p := peak.Load(); p > 20 {
+		t.Fatal(p)
+	}
+}
+
+func TestManageTasksWeighted_panic(t *testing.T) {
+	task := func(n int) (int, error) {
+		if n == 3 {
+			panic("3")
+		}
+		return n, nil
+	}
+	manager := func(in, out int, err error) ([]int, bool) {
+		return nil, true
+	}
+	r := try(func() {
+		flowmatic.ManageTasksWeighted(2, func(int) int64 { return 1 }, task, manager, 1, 2, 3, 4)
+	})
+	if in, v := taskPanic(r); in != 3 || v != "3" {
+		t.Fatal(r)
+	}
+}
+
+func TestManageTasksWeighted_nonPositive(t *testing.T) {
+	var cur, peak atomic.Int64
+	task := func(w int64) (int64, error) {
+		n := cur.Add(1)
+		defer cur.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		return w, nil
+	}
+	weightOf := func(w int64) int64 { return w }
+	inputs := make([]int64, 30)
+	for i := range inputs {
+		inputs[i] = -int64(i % 3)
+	}
+	// a nil manager is treated as NoExpand
+	flowmatic.ManageTasksWeighted(3, weightOf, task, nil, inputs...)
+	// inputs weighing 0 or less still count as 1
+	if p := peak.Load(); p < 1 || p > 3 {
+		t.Fatal(p)
+	}
+}
+
+func TestManageTasksWeighted_managerPanic(t *testing.T) {
+	task := func(n int) (int, error) {
+		return n, nil
+	}
+	manager := func(in, out int, err error) ([]int, bool) {
+		panic("manager!!")
+	}
+	r := try(func() {
+		flowmatic.ManageTasksWeighted(2, func(int) int64 { return 1 }, task, manager, 1, 2, 3, 4)
+	})
+	if r != "manager!!" {
+		t.Fatal(r)
+	}
+}
@@ -0,0 +1,74 @@
+package flowmatic
+
+import (
+	"context"
+	"errors"
+	"runtime"
+)
+
+// ManageTasksWindow starts numWorkers concurrent workers (or GOMAXPROCS workers if numWorkers < 1)
+// and runs task once for each input,
+// giving the results to sink strictly in input order.
+// No more than numWorkers inputs are started
+// beyond the oldest input whose result has not yet been given to sink,
+// so at most numWorkers results are buffered for reordering.
+// Returning false from sink halts processing.
+// Results of tasks which return ErrSkip are not given to sink.
+// If a task panics during execution,
+// the panic will be caught and rethrown in the parent Goroutine.
+func ManageTasksWindow[Input, Output any](numWorkers int, task Task[Input, Output], sink func(Output, error) bool, initial ...Input) {
+	if numWorkers < 1 {
+		numWorkers = runtime.GOMAXPROCS(0)
+	}
+	items := make([]sequenced[Input], len(initial))
+	for i, item := range initial {
+		items[i] = sequenced[Input]{i, item}
+	}
+	buf := newReorder[Result[sequenced[Input], Output]]()
+	q := &window[sequenced[Input]]{fifo: newFIFO(items...), size: numWorkers, delivered: &buf.next}
+	m := func(r Result[sequenced[Input], Output]) ([]sequenced[Input], Action) {
+		buf.add(r.In.seq, r)
+		for {
+			r, ok := buf.pop()
+			if !ok {
+				return nil, Continue
+			}
+			if errors.Is(r.Err, ErrSkip) {
+				continue
+			}
+			if !sink(r.Out, r.Err) {
+				return nil, StopNow
+			}
+		}
+	}
+	t := func(_ context.Context, in sequenced[Input]) (Output, error) {
+		return task(in.val)
+	}
+	_ = rethrow(manageFunc(context.Background(), numWorkers, t, m, q, keepSkips()))
+}
+
+// window is a first-in, first-out queue
+// which only dispatches an item
+// while fewer than size dispatched items are waiting to be delivered.
+type window[T any] struct {
+	*fifo[T]
+	size      int
+	popped    int
+	delivered *int
+}
+
+func (q *window[T]) peek() (t T, ok bool) {
+	if q.blocked() {
+		return t, false
+	}
+	return q.fifo.peek()
+}
+
+func (q *window[T]) blocked() bool {
+	return q.popped-*q.delivered >= q.size
+}
+
+func (q *window[T]) pop() {
+	q.fifo.pop()
+	q.popped++
+}
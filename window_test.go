@@ -0,0 +1,41 @@
+package flowmatic_test
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+func TestManageTasksWindow(t *testing.T) {
+	var started, delivered atomic.Int64
+	task := func(ms int) (int, error) {
+		if ahead := started.Add(1) - delivered.Load(); ahead > 3 {
+			t.Errorf("window exceeded: %d", ahead)
+		}
+		time.Sleep(time.Duration(ms) * time.Millisecond)
+		return ms, nil
+	}
+	var seen []int
+	sink := func(out int, err error) bool {
+		delivered.Add(1)
+		seen = append(seen, out)
+		return true
+	}
+	flowmatic.ManageTasksWindow(3, task, sink, 10, 1, 5, 0, 3, 2, 8, 0)
+	if s := fmt.Sprint(seen); s != "[10 1 5 0 3 2 8 0]" {
+		t.Fatal(s)
+	}
+
+	seen = nil
+	sink = func(out int, err error) bool {
+		seen = append(seen, out)
+		return len(seen) < 3
+	}
+	flowmatic.ManageTasksWindow(3, task, sink, 10, 1, 5, 0, 3, 2, 8, 0)
+	if s := fmt.Sprint(seen); s != "[10 1 5]" {
+		t.Fatal(s)
+	}
+}
@@ -0,0 +1,47 @@
+package flowmatic
+
+import (
+	"context"
+)
+
+// ManageTasksWorkerState is like ManageTasks,
+// but each worker has its own State,
+// such as a database connection or a buffer,
+// which is expensive to create and unsafe to share.
+// A worker calls newState with its worker ID,
+// numbered from 0 to numWorkers-1,
+// when it starts its first task,
+// and gives that State to every task it runs,
+// so a task may use its State without locking.
+// Once processing ends and the worker exits,
+// closeState, if not nil, is called with its State.
+// Workers are started as they are needed,
+// so fewer than numWorkers States may be created.
+// If a task panics during execution,
+// the States are still closed
+// before the panic is rethrown in the parent Goroutine.
+func ManageTasksWorkerState[Input, Output, State any](numWorkers int, newState func(worker int) State, closeState func(State), task func(State, Input) (Output, error), manager Manager[Input, Output], initial ...Input) {
+	newWorker := func(id int) (CTask[Input, Output], func()) {
+		var (
+			s       State
+			created bool
+		)
+		t := func(_ context.Context, in Input) (Output, error) {
+			// created in the task, so a panic in newState is caught like any other
+			if !created {
+				s = newState(id)
+				created = true
+			}
+			return task(s, in)
+		}
+		exit := func() {
+			if created && closeState != nil {
+				closeState(s)
+			}
+		}
+		return t, exit
+	}
+	// each worker runs the task returned by newWorker instead of a shared task
+	_ = rethrow(manage[Input, Output](context.Background(), numWorkers, nil, manager, newFIFO(initial...),
+		withWorker(newWorker)))
+}
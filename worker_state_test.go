@@ -0,0 +1,77 @@
+package flowmatic_test
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/carlmjohnson/flowmatic"
+)
+
+type conn struct {
+	id     int
+	inUse  atomic.Bool
+	closed int
+	uses   int
+}
+
+func TestManageTasksWorkerState(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		conns []*conn
+	)
+	newState := func(worker int) *conn {
+		c := &conn{id: worker}
+		mu.Lock()
+		conns = append(conns, c)
+		mu.Unlock()
+		return c
+	}
+	closeState := func(c *conn) {
+		c.closed++
+	}
+	task := func(c *conn, n int) (int, error) {
+		if !c.inUse.CompareAndSwap(false, true) {
+			t.Error("state shared by two tasks")
+		}
+		defer c.inUse.Store(false)
+		c.uses++
+		time.Sleep(time.Millisecond)
+		return n, nil
+	}
+	inputs := make([]int, 40)
+	flowmatic.ManageTasksWorkerState(4, newState, closeState, task, nil, inputs...)
+	if len(conns) < 1 || len(conns) > 4 {
+		t.Fatal(len(conns))
+	}
+	uses := 0
+	ids := make(map[int]bool)
+	for _, c := range conns {
+		// one State per worker, closed once when it exits
+		if ids[c.id] || c.closed != 1 || c.id < 0 || c.id >= 4 {
+			t.Fatal(c.id, c.closed)
+		}
+		ids[c.id] = true
+		uses += c.uses
+	}
+	if uses != 40 {
+		t.Fatal(uses)
+	}
+
+	// states are closed even if a task panics
+	conns = nil
+	r := try(func() {
+		flowmatic.ManageTasksWorkerState(2, newState, closeState, func(c *conn, n int) (int, error) {
+			panic("boom")
+		}, nil, 1, 2, 3)
+	})
+	if _, v := taskPanic(r); v != "boom" {
+		t.Fatal(r)
+	}
+	for _, c := range conns {
+		if c.closed != 1 {
+			t.Fatal(c.id, c.closed)
+		}
+	}
+}